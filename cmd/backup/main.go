@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	dir := flag.String("dir", "out/backups", "Directory to keep backups in, one timestamped subdirectory each")
+	keep := flag.Int("keep", 7, "Backups to keep; older ones are removed (0 keeps any number)")
+	keepDays := flag.Int("keep-days", 0, "Remove backups older than this many days (0 keeps them however old)")
+	snapshotDays := flag.Int("snapshot-days", 90, "Drop delete snapshots older than this many days, after which those deletes can't be undone (0 keeps them)")
+	list := flag.Bool("list", false, "List the backups and exit")
+	flag.Parse()
+
+	if *list {
+		backups, err := storage.ListBackups(*dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, b := range backups {
+			fmt.Printf("%s  %s  %-7s  schema v%d  %d leads\n", b.Dir, b.CreatedAt.Local().Format("2006-01-02 15:04"), b.Backend, b.SchemaVersion, b.Leads)
+		}
+		return
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	now := time.Now()
+	backupDir := storage.NewBackupDir(*dir, now)
+	if err := repo.Backup(ctx, backupDir); err != nil {
+		logger.Error("Backup failed", "dir", backupDir, "err", err)
+		os.Exit(1)
+	}
+	logger.Info("Backed up", "dir", backupDir)
+
+	removed, err := storage.PruneBackups(*dir, *keep, time.Duration(*keepDays)*24*time.Hour, now)
+	if err != nil {
+		logger.Error("Failed to remove old backups", "err", err)
+		os.Exit(1)
+	}
+	for _, d := range removed {
+		logger.Info("Removed old backup", "dir", d)
+	}
+
+	if *snapshotDays > 0 {
+		purged, err := repo.PurgeDeleteBatches(ctx, now.AddDate(0, 0, -*snapshotDays))
+		if err != nil {
+			logger.Error("Failed to drop old delete snapshots", "err", err)
+			os.Exit(1)
+		}
+		if purged > 0 {
+			logger.Info("Dropped old delete snapshots", "count", purged)
+		}
+	}
+}
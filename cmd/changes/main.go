@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	since := flag.String("since", "", "Report changes on or after this date (YYYY-MM-DD, default a week ago)")
+	until := flag.String("until", "", "Report changes before this date (YYYY-MM-DD, default tomorrow)")
+	run := flag.String("run", "", "Only changes made by this run ID")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from, err := parseDate(*since, today.AddDate(0, 0, -7))
+	if err != nil {
+		logger.Error("Invalid -since", "err", err)
+		os.Exit(1)
+	}
+	to, err := parseDate(*until, today.AddDate(0, 0, 1))
+	if err != nil {
+		logger.Error("Invalid -until", "err", err)
+		os.Exit(1)
+	}
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	changes, err := repo.ListChanges(ctx, from, to)
+	if err != nil {
+		logger.Error("Failed to list changes", "err", err)
+		os.Exit(1)
+	}
+
+	byKind := make(map[model.ChangeKind][]model.LeadChange)
+	for _, c := range changes {
+		if *run != "" && c.RunID != *run {
+			continue
+		}
+		byKind[c.Kind()] = append(byKind[c.Kind()], c)
+	}
+
+	fmt.Printf("\nChanges from %s to %s\n", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if len(byKind) == 0 {
+		fmt.Println("\nNo changes.")
+		return
+	}
+	for _, kind := range model.ChangeKinds {
+		list := byKind[kind]
+		if len(list) == 0 {
+			continue
+		}
+		fmt.Printf("\n%s (%d)\n", kind, len(list))
+		for _, c := range list {
+			fmt.Printf("  %s  %s  %-40s %s: %q -> %q\n", c.ChangedAt.Format("2006-01-02"), c.ABN, c.Name, c.Field, c.OldValue, c.NewValue)
+		}
+	}
+}
+
+func parseDate(raw string, fallback time.Time) (time.Time, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	return time.ParseInLocation("2006-01-02", raw, time.Local)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	abn := flag.String("abn", "", "Lead ABN")
+	name := flag.String("name", "", "Lead name, if the ABN isn't to hand")
+	status := flag.String("status", "", "Set the deal status: new, researching, contacted, meeting, passed or do-not-pursue; with -list, only these (comma-separated)")
+	owner := flag.String("owner", "", "Set who owns the deal (\"-\" to clear); with -list, only these owners (comma-separated)")
+	next := flag.String("next", "", "Set the next action, e.g. \"call back about the lease\" (\"-\" to clear it and its due date)")
+	due := flag.String("due", "", "Set when the next action is due: a date (2026-11-30) or a duration (7d); with -list, only actions due by then")
+	note := flag.String("note", "", "Add a note")
+	author := flag.String("author", os.Getenv("USER"), "Who wrote the note")
+	list := flag.Bool("list", false, "List deals in progress, soonest next action first (researching, contacted and meeting unless -status is given)")
+	limit := flag.Int("limit", 0, "With -list, show at most this many deals (0 for all)")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	now := time.Now()
+	dueAt, err := model.ParseExpiry(*due, now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -due: %v\n", err)
+		os.Exit(1)
+	}
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	if *list {
+		q := storage.LeadQuery{DueBy: dueAt, Sort: storage.SortNextAction, Limit: *limit}
+		if *status != "" {
+			if q.DealStatuses, err = model.ParseDealStatuses(*status); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			q.DealStatuses = []model.DealStatus{model.DealResearching, model.DealContacted, model.DealMeeting}
+		}
+		if *owner != "" {
+			q.Owners = strings.Split(*owner, ",")
+		}
+		leads, err := repo.FindLeads(ctx, q)
+		if err != nil {
+			logger.Error("Failed to list deals", "err", err)
+			os.Exit(1)
+		}
+		for _, l := range leads {
+			nextAt := ""
+			if !l.Deal.NextActionAt.IsZero() {
+				nextAt = l.Deal.NextActionAt.Format("2006-01-02")
+				if l.Deal.Due(now) {
+					nextAt += " due"
+				}
+			}
+			fmt.Printf("%-11s  %-13s  %-12s  %-14s  %-40s  %s\n", l.ABN, l.Deal.Status, l.Deal.Owner, nextAt, l.Name, l.Deal.NextAction)
+		}
+		return
+	}
+
+	if *abn == "" {
+		if *name == "" {
+			fmt.Fprintf(os.Stderr, "Error: -abn or -name is required\n")
+			os.Exit(1)
+		}
+		found, err := repo.LookupLead(ctx, "", *name)
+		if err != nil {
+			logger.Error("Lead lookup failed", "err", err)
+			os.Exit(1)
+		}
+		if found == nil {
+			logger.Error("No lead with that name", "name", *name)
+			os.Exit(1)
+		}
+		*abn = found.ABN
+	}
+	lead, err := repo.GetLead(ctx, *abn)
+	if err != nil {
+		logger.Error("Lead lookup failed", "err", err)
+		os.Exit(1)
+	}
+	if lead == nil {
+		logger.Error("No lead with that ABN", "abn", *abn)
+		os.Exit(1)
+	}
+
+	// Change only what was asked for
+	deal := lead.Deal
+	changed := false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "status":
+			s, err := model.ParseDealStatus(*status)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			deal.Status = s
+		case "owner":
+			deal.Owner = strings.TrimSpace(*owner)
+			if deal.Owner == "-" {
+				deal.Owner = ""
+			}
+		case "next":
+			deal.NextAction = strings.TrimSpace(*next)
+			if deal.NextAction == "-" {
+				deal.NextAction, deal.NextActionAt = "", time.Time{}
+			}
+		case "due":
+			// Actions are due on a day, not at a time
+			y, m, d := dueAt.Date()
+			deal.NextActionAt = time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+			if dueAt.IsZero() {
+				deal.NextActionAt = time.Time{}
+			}
+		default:
+			return
+		}
+		changed = true
+	})
+
+	if changed {
+		deal.UpdatedAt = now
+		if err := repo.SetDeal(ctx, lead.ABN, deal); err != nil {
+			logger.Error("Failed to update deal", "err", err)
+			os.Exit(1)
+		}
+		// Moving a deal on is also an outcome, which ranking learns from
+		if outcome, ok := deal.Status.Outcome(); ok && deal.Status != lead.Deal.Status {
+			if err := repo.RecordOutcome(ctx, model.LeadOutcome{ABN: lead.ABN, Outcome: outcome, Note: *note}); err != nil {
+				logger.Error("Failed to record outcome", "err", err)
+				os.Exit(1)
+			}
+		}
+		logger.Info("Updated deal", "abn", lead.ABN, "status", deal.Status, "owner", deal.Owner)
+	}
+	if *note != "" {
+		if _, err := repo.AddLeadNote(ctx, model.LeadNote{ABN: lead.ABN, Note: *note, Author: *author}); err != nil {
+			logger.Error("Failed to add note", "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Added note", "abn", lead.ABN)
+	}
+	if changed || *note != "" {
+		return
+	}
+
+	// Nothing to change: show the deal
+	notes, err := repo.ListLeadNotes(ctx, lead.ABN)
+	if err != nil {
+		logger.Error("Failed to load notes", "err", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\n%s (%s)\n", lead.Name, lead.ABN)
+	fmt.Printf("  %-12s %s\n", "status", deal.Status)
+	fmt.Printf("  %-12s %s\n", "owner", deal.Owner)
+	if deal.NextAction != "" || !deal.NextActionAt.IsZero() {
+		nextAt := ""
+		if !deal.NextActionAt.IsZero() {
+			nextAt = " (due " + deal.NextActionAt.Format("2006-01-02") + ")"
+		}
+		fmt.Printf("  %-12s %s%s\n", "next action", deal.NextAction, nextAt)
+	}
+	if !deal.UpdatedAt.IsZero() {
+		fmt.Printf("  %-12s %s\n", "updated", deal.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	if len(notes) > 0 {
+		fmt.Printf("\nNotes\n")
+		for _, n := range notes {
+			fmt.Printf("  %s  %-12s  %s\n", n.CreatedAt.Local().Format("2006-01-02 15:04"), n.Author, n.Note)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	merge := flag.String("merge", "", "Merge the -abn leads into this lead, the one to keep")
+	abn := flag.String("abn", "", "With -merge, the leads to fold into it (comma-separated)")
+	reject := flag.String("reject", "", "Mark these leads as not duplicates of each other (comma-separated ABNs)")
+	rejections := flag.Bool("rejections", false, "List the pairs marked as not duplicates")
+	author := flag.String("author", os.Getenv("USER"), "Who merged or rejected the leads")
+	flag.Parse()
+
+	if *merge != "" && *abn == "" {
+		fmt.Fprintf(os.Stderr, "Error: -merge needs -abn, the leads to merge into it\n")
+		os.Exit(1)
+	}
+	var rejected []string
+	if *reject != "" {
+		for _, a := range strings.Split(*reject, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				rejected = append(rejected, a)
+			}
+		}
+		if len(rejected) < 2 {
+			fmt.Fprintf(os.Stderr, "Error: -reject needs at least two ABNs\n")
+			os.Exit(1)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case *merge != "":
+		survivor := strings.TrimSpace(*merge)
+		merged := strings.Split(*abn, ",")
+
+		fmt.Println("\nMerge into:")
+		showLead(ctx, logger, repo, survivor)
+		fmt.Println("these leads, which are then deleted and suppressed:")
+		for _, a := range merged {
+			showLead(ctx, logger, repo, strings.TrimSpace(a))
+		}
+		fmt.Print("\nAre you sure? (yes/no): ")
+
+		reader := bufio.NewReader(os.Stdin)
+		response, _ := reader.ReadString('\n')
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response != "yes" && response != "y" {
+			fmt.Println("Cancelled.")
+			os.Exit(0)
+		}
+
+		batch, err := repo.MergeLeads(ctx, survivor, merged, *author)
+		if err != nil {
+			logger.Error("Merge failed", "survivor", survivor, "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Merged leads", "survivor", survivor, "merged", batch.Leads, "batch", batch.ID)
+		fmt.Printf("\nTo bring the merged leads back and lift their suppressions: undo %s\n", batch.ID)
+
+	case len(rejected) > 0:
+		pairs := 0
+		for i := range rejected {
+			for j := i + 1; j < len(rejected); j++ {
+				if err := repo.RejectDuplicate(ctx, rejected[i], rejected[j], *author); err != nil {
+					logger.Error("Failed to reject pair", "a", rejected[i], "b", rejected[j], "err", err)
+					os.Exit(1)
+				}
+				pairs++
+			}
+		}
+		logger.Info("Marked as not duplicates", "abns", strings.Join(rejected, ","), "pairs", pairs)
+
+	case *rejections:
+		list, err := repo.ListDuplicateRejections(ctx)
+		if err != nil {
+			logger.Error("Failed to list rejections", "err", err)
+			os.Exit(1)
+		}
+		for _, rj := range list {
+			fmt.Printf("%-11s  %-11s  %s  %s\n", rj.A, rj.B, rj.RejectedAt.Local().Format("2006-01-02"), rj.Author)
+		}
+
+	default:
+		pairs, err := repo.FindDuplicates(ctx)
+		if err != nil {
+			logger.Error("Duplicate detection failed", "err", err)
+			os.Exit(1)
+		}
+		rejections, err := repo.ListDuplicateRejections(ctx)
+		if err != nil {
+			logger.Error("Failed to list rejections", "err", err)
+			os.Exit(1)
+		}
+		groups := model.GroupDuplicates(pairs, rejections)
+		for i, g := range groups {
+			var leads []model.Lead
+			for _, a := range g.ABNs {
+				l, err := repo.GetLead(ctx, a)
+				if err != nil {
+					logger.Error("Lead lookup failed", "abn", a, "err", err)
+					os.Exit(1)
+				}
+				if l != nil {
+					leads = append(leads, *l)
+				}
+			}
+			keep := model.SuggestSurvivor(leads)
+			merge, apart := g.MergePlan(keep)
+
+			reasons := make([]string, len(g.Reasons))
+			for j, r := range g.Reasons {
+				reasons[j] = string(r)
+			}
+			fmt.Printf("\nGroup %d (%s)\n", i+1, strings.Join(reasons, ", "))
+			for _, l := range leads {
+				mark := ""
+				switch {
+				case l.ABN == keep:
+					mark = "keep"
+				case apart[l.ABN] != "":
+					mark = "not"
+				}
+				printLead(l, mark)
+			}
+			if len(merge) > 0 {
+				fmt.Printf("  dedupe -merge %s -abn %s\n", keep, strings.Join(merge, ","))
+			}
+			for _, l := range leads {
+				if other := apart[l.ABN]; other != "" {
+					fmt.Printf("  (%s is left out: marked as not a duplicate of %s)\n", l.ABN, other)
+				}
+			}
+		}
+		if len(groups) > 0 {
+			fmt.Printf("\nGroups to review: %d. For leads that aren't duplicates: dedupe -reject ABN,ABN\n", len(groups))
+		}
+	}
+}
+
+// showLead prints a lead by ABN, or exits if there's no such lead.
+func showLead(ctx context.Context, logger *slog.Logger, repo storage.Repository, abn string) {
+	l, err := repo.GetLead(ctx, abn)
+	if err != nil {
+		logger.Error("Lead lookup failed", "abn", abn, "err", err)
+		os.Exit(1)
+	}
+	if l == nil {
+		logger.Error("No lead with that ABN", "abn", abn)
+		os.Exit(1)
+	}
+	printLead(*l, "")
+}
+
+func printLead(l model.Lead, mark string) {
+	name := l.Name
+	if l.MainTradingName != "" && model.NormalizeName(l.MainTradingName) != model.NormalizeName(l.Name) {
+		name += " (t/a " + l.MainTradingName + ")"
+	}
+	fmt.Printf("  %-4s  %-11s  %5.1f  %-3s  %-4s  %-15s  %-24s  %-20s  %s\n",
+		mark, l.ABN, l.Score.Total, l.State, l.Postcode, l.Phone, model.WebsiteDomain(l.BusinessURL), strings.Join(l.Sources, ","), name)
+}
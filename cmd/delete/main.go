@@ -8,7 +8,10 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/source"
 	"github.com/shanehull/sourcerer/internal/storage"
 )
 
@@ -16,12 +19,16 @@ func main() {
 	name := flag.String("name", "", "Lead name to delete")
 	abn := flag.String("abn", "", "Lead ABN to delete")
 	age := flag.Int("age", 0, "Lead age in years (for matching)")
-	source := flag.String("source", "", "Lead source (for matching)")
+	sourceFlag := flag.String("source", "", "Lead sources, e.g. rto,northlink or NorthLink-FoodMfg (for matching)")
 	state := flag.String("state", "", "Lead state (for matching)")
-	entityType := flag.String("entity-type", "", "Lead entity type (for matching)")
+	entityType := flag.String("entity-type", "", "Lead entity type codes or kinds, e.g. PUB,DTT or trust,government (for matching)")
 	gstOnly := flag.Bool("not-gst", false, "Delete leads NOT registered for GST")
-	notPrivate := flag.Bool("not-private", false, "Delete leads that are not private entities (public, government, sole trader, etc)")
-	dbPath := flag.String("db", "out/sourcing.duckdb", "Path to DuckDB file")
+	notPrivate := flag.Bool("not-private", false, "Delete leads that are not private companies or partnerships (public, government, sole trader, trust, etc)")
+	suppress := flag.Bool("suppress", false, "Also suppress the deleted leads' ABNs so the pipeline doesn't bring them back")
+	reason := flag.String("reason", "", "With -suppress, why the leads are suppressed")
+	author := flag.String("author", os.Getenv("USER"), "Who is deleting (and suppressing) them, recorded with the undo snapshot")
+	expires := flag.String("expires", "", "With -suppress, when the suppressions lapse: a date (2027-06-30) or a duration (90d); default never")
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
 	flag.Parse()
 
 	// Check if at least one filter flag was explicitly provided
@@ -38,7 +45,7 @@ func main() {
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	repo, err := storage.NewDuckDBRepo(*dbPath, logger)
+	repo, err := storage.Open(*dbPath, logger)
 	if err != nil {
 		logger.Error("DB connection failed", "err", err)
 		os.Exit(1)
@@ -46,40 +53,64 @@ func main() {
 	defer repo.Close()
 
 	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
 
-	// Build filter conditions - check which flags were provided
-	filters := map[string]interface{}{}
+	// Build the query from the flags that were provided
+	var q storage.LeadQuery
 	flag.Visit(func(f *flag.Flag) {
 		switch f.Name {
 		case "name":
-			filters["name"] = *name
+			q.Name = *name
 		case "abn":
-			filters["abn"] = *abn
+			q.ABNs = []string{*abn}
 		case "age":
 			if *age > 0 {
-				filters["age"] = *age
+				q.MinAge, q.MaxAge = *age, *age
 			}
 		case "source":
-			filters["source"] = *source
+			names, err := source.ResolveNames(strings.Split(*sourceFlag, ","))
+			if err != nil {
+				logger.Error("Invalid source", "err", err)
+				os.Exit(1)
+			}
+			q.Sources = names
 		case "state":
-			filters["state"] = *state
+			q.States = []string{*state}
 		case "entity-type":
-			filters["entity_type"] = *entityType
+			codes, err := model.ParseEntityTypes(*entityType)
+			if err != nil {
+				logger.Error("Invalid entity type", "err", err)
+				os.Exit(1)
+			}
+			q.EntityTypes = codes
 		case "not-gst":
 			if *gstOnly {
-				filters["not_gst"] = true
+				q.GST = storage.Bool(false)
 			}
 		case "not-private":
 			if *notPrivate {
-				filters["not_private"] = true
+				q.Private = storage.Bool(false)
 			}
 		}
 	})
+	if q.IsZero() {
+		fmt.Fprintf(os.Stderr, "Error: the filters given match every lead\n")
+		os.Exit(1)
+	}
+	expiresAt, err := model.ParseExpiry(*expires, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Confirm deletion
 	fmt.Println("\nDelete with filters:")
-	for k, v := range filters {
-		fmt.Printf("  %s: %v\n", k, v)
+	fmt.Printf("  %s\n", q)
+	if *suppress {
+		fmt.Println("and suppress the deleted leads")
 	}
 	fmt.Print("\nAre you sure? (yes/no): ")
 	
@@ -91,15 +122,25 @@ func main() {
 		os.Exit(0)
 	}
 
-	rowsDeleted, err := repo.DeleteLeadByFilters(ctx, filters)
+	// The suppressions go in with the delete, so undoing it lifts them too
+	var suppression *model.Suppression
+	if *suppress {
+		suppression = &model.Suppression{Reason: *reason, Author: *author, ExpiresAt: expiresAt}
+	}
+	batch, err := repo.DeleteLeads(ctx, q, *author, suppression)
 	if err != nil {
-		logger.Error("Delete failed", "filters", filters, "err", err)
+		logger.Error("Delete failed", "filters", q.String(), "err", err)
 		os.Exit(1)
 	}
 
-	if rowsDeleted == 0 {
-		logger.Warn("No records matched the filters", "filters", filters)
+	if batch.Leads == 0 {
+		logger.Warn("No records matched the filters", "filters", q.String())
 	} else {
-		logger.Info("Deleted successfully", "filters", filters, "rows_deleted", rowsDeleted)
+		logger.Info("Deleted successfully", "filters", q.String(), "leads_deleted", batch.Leads, "suppressed", *suppress, "batch", batch.ID)
+		if *suppress {
+			fmt.Printf("\nTo restore them and lift their suppressions: undo %s\n", batch.ID)
+		} else {
+			fmt.Printf("\nTo restore them: undo %s\n", batch.ID)
+		}
 	}
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/registry"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	acncPath := flag.String("acnc", "", "ACNC charity register CSV (datadotgov_main.csv)")
+	atoPath := flag.String("ato", "", "ATO Corporate Tax Transparency CSV for one income year")
+	atoYear := flag.Int("ato-year", 0, "Income year the -ato file covers, by its end year (2023 for 2022-23)")
+	flag.Parse()
+
+	if *acncPath == "" && *atoPath == "" {
+		fmt.Fprintf(os.Stderr, "Error: nothing to import (-acnc, -ato)\n")
+		os.Exit(1)
+	}
+	if *atoPath != "" && (*atoYear < 2000 || *atoYear > time.Now().Year()) {
+		fmt.Fprintf(os.Stderr, "Error: -ato needs a valid -ato-year\n")
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	if *acncPath != "" {
+		f, err := os.Open(*acncPath)
+		if err != nil {
+			logger.Error("Failed to open ACNC register", "err", err)
+			os.Exit(1)
+		}
+		charities, err := registry.ParseACNC(f)
+		f.Close()
+		if err != nil {
+			logger.Error("Failed to parse ACNC register", "err", err)
+			os.Exit(1)
+		}
+
+		flagged, err := repo.ImportCharities(ctx, charities)
+		if err != nil {
+			logger.Error("ACNC import failed", "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Imported ACNC register", "charities", len(charities), "leads_flagged", flagged)
+	}
+
+	if *atoPath != "" {
+		f, err := os.Open(*atoPath)
+		if err != nil {
+			logger.Error("Failed to open tax transparency data", "err", err)
+			os.Exit(1)
+		}
+		records, err := registry.ParseTaxTransparency(f, *atoYear)
+		f.Close()
+		if err != nil {
+			logger.Error("Failed to parse tax transparency data", "err", err)
+			os.Exit(1)
+		}
+
+		matched, err := repo.ImportTaxRecords(ctx, *atoYear, records)
+		if err != nil {
+			logger.Error("Tax transparency import failed", "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Imported tax transparency data", "year", *atoYear, "records", len(records), "leads_matched", matched)
+	}
+}
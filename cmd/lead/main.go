@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/precedence"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	abn := flag.String("abn", "", "Lead ABN")
+	name := flag.String("name", "", "Lead name, if the ABN isn't to hand")
+	precedenceConfig := flag.String("precedence", "", "Field precedence rules JSON to use instead of the bundled rules")
+	flag.Parse()
+
+	if *abn == "" && *name == "" {
+		fmt.Fprintf(os.Stderr, "Error: -abn or -name is required\n")
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	rules := precedence.Default()
+	if *precedenceConfig != "" {
+		var err error
+		rules, err = precedence.LoadFile(*precedenceConfig)
+		if err != nil {
+			logger.Error("Failed to load precedence config", "err", err)
+			os.Exit(1)
+		}
+	}
+	resolver := precedence.NewResolver(rules)
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	if *abn == "" {
+		found, err := repo.LookupLead(ctx, "", *name)
+		if err != nil {
+			logger.Error("Lead lookup failed", "err", err)
+			os.Exit(1)
+		}
+		if found == nil {
+			logger.Error("No lead with that name", "name", *name)
+			os.Exit(1)
+		}
+		*abn = found.ABN
+	}
+
+	lead, err := repo.GetLead(ctx, *abn)
+	if err != nil {
+		logger.Error("Lead lookup failed", "err", err)
+		os.Exit(1)
+	}
+	if lead == nil {
+		logger.Error("No lead with that ABN", "abn", *abn)
+		os.Exit(1)
+	}
+	sources, err := repo.GetLeadSources(ctx, lead.ABN)
+	if err != nil {
+		logger.Error("Failed to load sources", "err", err)
+		os.Exit(1)
+	}
+	values, err := repo.GetFieldValues(ctx, lead.ABN)
+	if err != nil {
+		logger.Error("Failed to load field values", "err", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n%s (%s)\n", lead.Name, lead.ABN)
+	fmt.Printf("  %-18s %s (%s)\n", "entity type", lead.EntityType, lead.EntityTypeCode)
+	fmt.Printf("  %-18s %s\n", "registered", lead.RegistrationDate.Format("2006-01-02"))
+	fmt.Printf("  %-18s %.1f\n", "score", lead.Score.Total)
+	if !lead.EnrichedAt.IsZero() {
+		fmt.Printf("  %-18s %s\n", "abr confirmed", lead.EnrichedAt.Format("2006-01-02"))
+	}
+	if rejected := lead.Criteria.RejectedBy(); rejected != "" {
+		fmt.Printf("  %-18s %s\n", "rejected by", rejected)
+	}
+	if lead.Deal.Status != model.DealNew || lead.Deal.Owner != "" {
+		fmt.Printf("  %-18s %s, owner %s (see deal -abn %s)\n", "deal", lead.Deal.Status, lead.Deal.Owner, lead.ABN)
+	}
+	if lead.Industry.Class != "" || lead.Industry.Division != "" {
+		fmt.Printf("  %-18s %s %s\n", "industry", lead.Industry.Code(), lead.Industry.Title)
+	}
+
+	tags, err := repo.GetLeadTags(ctx, lead.ABN)
+	if err != nil {
+		logger.Error("Failed to load tags", "err", err)
+		os.Exit(1)
+	}
+	if len(tags) > 0 {
+		fmt.Printf("  %-18s %s\n", "tags", strings.Join(tags, ", "))
+	}
+
+	fmt.Printf("\nSources\n")
+	for _, s := range sources {
+		fmt.Printf("  %-22s first %s, last %s  %q  %s  %s\n", s.Source,
+			s.FirstSeen.Format("2006-01-02"), s.LastSeen.Format("2006-01-02"), s.RawName, s.Category, s.FoundAtURL)
+	}
+
+	// Resolve from what's recorded, so the marked value is what the rules
+	// pick now even if the stored column predates them
+	canonical := resolver.Resolve(values)
+	byField := make(map[string][]model.FieldValue)
+	for _, v := range values {
+		byField[v.Field] = append(byField[v.Field], v)
+	}
+
+	fmt.Printf("\nFields (* is the canonical value)\n")
+	for _, field := range model.AssertedFields {
+		rule := resolver.Rule(field)
+		fmt.Printf("  %-18s %s\n", field, lead.Field(field))
+		if len(rule.Prefer) > 0 {
+			fmt.Printf("  %-18s prefer %v, then %s\n", "", rule.Prefer, rule.Then)
+		} else {
+			fmt.Printf("  %-18s %s wins\n", "", rule.Then)
+		}
+		for _, v := range byField[field] {
+			mark := " "
+			if c, ok := canonical[field]; ok && c.Asserter == v.Asserter {
+				mark = "*"
+			}
+			fmt.Printf("  %-16s %s %-22s %s  %s\n", "", mark, v.Asserter, v.AssertedAt.Format("2006-01-02 15:04"), v.Value)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/export"
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/source"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+// filterFlags select the leads to add to or remove from a list
+var filterFlags = map[string]bool{
+	"abn": true, "name": true, "states": true, "sources": true, "category": true,
+	"age": true, "industries": true, "deal-status": true, "tagged": true, "in-list": true,
+}
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	add := flag.String("add", "", "Add the leads the filters select to this list, creating it if need be (e.g. \"Q3 shortlist\")")
+	remove := flag.String("remove", "", "Take the leads the filters select off this list")
+	del := flag.String("delete", "", "Delete this list; its leads are left as they are")
+	show := flag.String("show", "", "Show the leads on this list")
+	exportName := flag.String("export", "", "Export the leads on this list")
+	outPath := flag.String("out", "", "With -export, the output path; its extension picks the format unless -format is set (default out/list-<name>.csv)")
+	formatFlag := flag.String("format", "", "With -export, the output format: csv, jsonl, parquet or xlsx")
+	columnsRaw := flag.String("columns", "", "With -export, only these columns, in this order (comma-separated)")
+	profileName := flag.String("profile", "", "With -export, output for import elsewhere instead: hubspot, salesforce, pipedrive or vcard")
+	author := flag.String("author", os.Getenv("USER"), "Who made the list")
+	abn := flag.String("abn", "", "Leads with these ABNs (comma-separated)")
+	name := flag.String("name", "", "Leads whose name contains this (case-insensitive)")
+	states := flag.String("states", "", "Leads in these states (e.g. VIC,NSW)")
+	sourcesFlag := flag.String("sources", "", "Leads listed by these sources (e.g. rto,northlink)")
+	category := flag.String("category", "", "Leads in this source category (e.g. Manufacturing)")
+	age := flag.Int("age", 0, "Leads at least this many years old")
+	industries := flag.String("industries", "", "Leads in these ANZSIC divisions, subdivisions or classes (e.g. C,22)")
+	dealStatus := flag.String("deal-status", "", "Leads at these deal statuses (e.g. meeting)")
+	tagged := flag.String("tagged", "", "Leads that carry any of these tags")
+	inList := flag.String("in-list", "", "Leads on any of these lists (comma-separated)")
+	flag.Parse()
+
+	hasFilters := false
+	flag.Visit(func(f *flag.Flag) {
+		if filterFlags[f.Name] {
+			hasFilters = true
+		}
+	})
+	if (*add != "" || *remove != "") && !hasFilters {
+		fmt.Fprintf(os.Stderr, "Error: at least one filter is required (-abn, -name, -states, -sources, -category, -age, -industries, -deal-status, -tagged or -in-list)\n")
+		os.Exit(1)
+	}
+
+	var q storage.LeadQuery
+	var err error
+	if *abn != "" {
+		q.ABNs = strings.Split(*abn, ",")
+	}
+	q.NameContains = *name
+	if *states != "" {
+		q.States = strings.Split(*states, ",")
+	}
+	if *sourcesFlag != "" {
+		if q.Sources, err = source.ResolveNames(strings.Split(*sourcesFlag, ",")); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	q.Category = *category
+	q.MinAge = *age
+	if *industries != "" {
+		q.Industries = strings.Split(strings.ToUpper(*industries), ",")
+	}
+	if q.DealStatuses, err = model.ParseDealStatuses(*dealStatus); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	q.Tags = model.ParseTags(*tagged)
+	if *inList != "" {
+		for _, l := range strings.Split(*inList, ",") {
+			q.Lists = append(q.Lists, strings.TrimSpace(l))
+		}
+	}
+	if hasFilters && q.IsZero() {
+		fmt.Fprintf(os.Stderr, "Error: the filters given match every lead\n")
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	// The list a command works on must exist, except when adding creates it
+	mustExist := func(name string) {
+		l, err := repo.GetList(ctx, name)
+		if err != nil {
+			logger.Error("List lookup failed", "err", err)
+			os.Exit(1)
+		}
+		if l == nil {
+			logger.Error("No list with that name", "list", name)
+			os.Exit(1)
+		}
+	}
+
+	switch {
+	case *add != "":
+		added, err := repo.AddToList(ctx, *add, q, *author)
+		if err != nil {
+			logger.Error("Failed to add to list", "list", *add, "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Added leads to list", "list", *add, "filters", q.String(), "added", added)
+
+	case *remove != "":
+		mustExist(*remove)
+		removed, err := repo.RemoveFromList(ctx, *remove, q)
+		if err != nil {
+			logger.Error("Failed to remove from list", "list", *remove, "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Removed leads from list", "list", *remove, "filters", q.String(), "removed", removed)
+
+	case *del != "":
+		deleted, err := repo.DeleteList(ctx, *del)
+		if err != nil {
+			logger.Error("Failed to delete list", "list", *del, "err", err)
+			os.Exit(1)
+		}
+		if !deleted {
+			logger.Warn("No list with that name", "list", *del)
+			return
+		}
+		logger.Info("Deleted list", "list", *del)
+
+	case *show != "":
+		mustExist(*show)
+		q.Lists = []string{strings.TrimSpace(*show)}
+		leads, err := repo.FindLeads(ctx, q)
+		if err != nil {
+			logger.Error("Failed to load list", "list", *show, "err", err)
+			os.Exit(1)
+		}
+		for _, l := range leads {
+			fmt.Printf("%-11s  %5.1f  %-3s  %-13s  %s\n", l.ABN, l.Score.Total, l.State, l.Deal.Status, l.Name)
+		}
+
+	case *exportName != "":
+		mustExist(*exportName)
+		q.Lists = []string{strings.TrimSpace(*exportName)}
+		var profile export.Profile
+		if *profileName != "" {
+			if profile, err = export.ProfileByName(*profileName); err != nil {
+				logger.Error("Invalid -profile", "err", err)
+				os.Exit(1)
+			}
+		}
+		if *outPath == "" {
+			ext := *formatFlag
+			switch {
+			case profile != nil:
+				ext = profile.Ext()
+			case ext == "":
+				ext = "csv"
+			}
+			*outPath = filepath.Join("out", "list-"+model.NormalizeTag(*exportName)+"."+strings.ToLower(ext))
+		}
+		if profile != nil {
+			err = export.LeadsProfile(ctx, repo, *outPath, profile, q)
+		} else {
+			format, ferr := export.Format(*formatFlag, *outPath)
+			if ferr != nil {
+				logger.Error("Invalid output format", "err", ferr)
+				os.Exit(1)
+			}
+			columns, cerr := storage.ParseExportColumns(*columnsRaw)
+			if cerr != nil {
+				logger.Error("Invalid -columns", "err", cerr)
+				os.Exit(1)
+			}
+			err = export.Leads(ctx, repo, *outPath, format, q, columns)
+		}
+		if err != nil {
+			logger.Error("Export failed", "list", *exportName, "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Exported list", "list", *exportName, "output", *outPath)
+
+	default:
+		lists, err := repo.ListLists(ctx)
+		if err != nil {
+			logger.Error("Failed to list lists", "err", err)
+			os.Exit(1)
+		}
+		for _, l := range lists {
+			fmt.Printf("%-30s  %5d leads  %s  %s\n", l.Name, l.Leads, l.CreatedAt.Local().Format("2006-01-02"), l.Author)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	up := flag.Bool("up", false, "Apply pending migrations (every command also does this on start)")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if *up {
+		applied, err := repo.Migrate(ctx)
+		if err != nil {
+			logger.Error("Migration failed", "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Migrated", "applied", len(applied))
+	}
+
+	current, err := repo.SchemaVersion(ctx)
+	if err != nil {
+		logger.Error("Failed to read schema version", "err", err)
+		os.Exit(1)
+	}
+	applied, err := repo.AppliedMigrations(ctx)
+	if err != nil {
+		logger.Error("Failed to read schema migrations", "err", err)
+		os.Exit(1)
+	}
+	appliedAt := make(map[int]string)
+	for _, m := range applied {
+		appliedAt[m.Version] = m.AppliedAt.Format("2006-01-02 15:04")
+	}
+
+	latest := storage.LatestVersion()
+	fmt.Printf("\nSchema version %d (this build: %d)\n\n", current, latest)
+	for _, m := range storage.Migrations() {
+		status := "pending"
+		if at, ok := appliedAt[m.Version]; ok {
+			status = "applied " + at
+		}
+		fmt.Printf("  %03d  %-22s %s\n", m.Version, m.Name, status)
+	}
+
+	if current > latest {
+		fmt.Printf("\nThe database is newer than this build; upgrade before using it.\n")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	abn := flag.String("abn", "", "Lead ABN")
+	name := flag.String("name", "", "Lead name, if the ABN isn't to hand")
+	outcome := flag.String("outcome", "", "Outcome: contacted, meeting, passed or rejected")
+	note := flag.String("note", "", "Optional note")
+	list := flag.Bool("list", false, "List recorded outcomes")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	if *list {
+		outcomes, err := repo.ListOutcomes(ctx)
+		if err != nil {
+			logger.Error("Failed to list outcomes", "err", err)
+			os.Exit(1)
+		}
+		for _, o := range outcomes {
+			fmt.Printf("%s  %s  %-9s  %s\n", o.RecordedAt.Format("2006-01-02 15:04"), o.ABN, o.Outcome, o.Note)
+		}
+		return
+	}
+
+	parsed, err := model.ParseOutcome(*outcome)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *abn == "" {
+		if *name == "" {
+			fmt.Fprintf(os.Stderr, "Error: -abn or -name is required\n")
+			os.Exit(1)
+		}
+		lead, err := repo.GetLeadByName(ctx, *name)
+		if err != nil {
+			logger.Error("Lead lookup failed", "err", err)
+			os.Exit(1)
+		}
+		if lead == nil {
+			logger.Error("No lead with that name", "name", *name)
+			os.Exit(1)
+		}
+		*abn = lead.ABN
+	}
+
+	if err := repo.RecordOutcome(ctx, model.LeadOutcome{ABN: *abn, Outcome: parsed, Note: *note}); err != nil {
+		logger.Error("Failed to record outcome", "err", err)
+		os.Exit(1)
+	}
+	logger.Info("Recorded outcome", "abn", *abn, "outcome", parsed)
+}
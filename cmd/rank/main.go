@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/rank"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	train := flag.Bool("train", false, "Train a model on recorded outcomes and print its coefficients")
+	scoreLeads := flag.Bool("score", false, "Score unreviewed leads with the latest model (rank_score)")
+	show := flag.Bool("show", false, "Print the latest model's coefficients")
+	explain := flag.String("explain", "", "Explain the latest model's prediction for this ABN")
+	flag.Parse()
+
+	if !*train && !*scoreLeads && !*show && *explain == "" {
+		fmt.Fprintf(os.Stderr, "Error: nothing to do (-train, -score, -show or -explain)\n")
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	leads, err := repo.ListLeads(ctx)
+	if err != nil {
+		logger.Error("Failed to load leads", "err", err)
+		os.Exit(1)
+	}
+	outcomes, err := repo.ListOutcomes(ctx)
+	if err != nil {
+		logger.Error("Failed to load outcomes", "err", err)
+		os.Exit(1)
+	}
+	labels := rank.Labels(outcomes)
+	now := time.Now()
+
+	var m *rank.Model
+	if *train {
+		var samples []rank.Sample
+		for _, l := range leads {
+			if progressed, ok := labels[l.ABN]; ok {
+				samples = append(samples, rank.Sample{Lead: l, Progressed: progressed})
+			}
+		}
+		m, err = rank.Train(samples, now)
+		if err != nil {
+			logger.Error("Training failed", "err", err)
+			os.Exit(1)
+		}
+		data, _ := json.Marshal(m)
+		if err := repo.SaveRankModel(ctx, m.ID, m.TrainedAt, data); err != nil {
+			logger.Error("Failed to save model", "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Trained model", "id", m.ID, "samples", m.Samples, "progressed", m.Positives)
+		printCoefficients(m)
+	} else {
+		data, err := repo.LatestRankModel(ctx)
+		if err != nil {
+			logger.Error("Failed to load model", "err", err)
+			os.Exit(1)
+		}
+		if data == nil {
+			logger.Error("No trained model yet, run with -train")
+			os.Exit(1)
+		}
+		m = &rank.Model{}
+		if err := json.Unmarshal(data, m); err != nil {
+			logger.Error("Stored model is invalid", "err", err)
+			os.Exit(1)
+		}
+		if *show {
+			printCoefficients(m)
+		}
+	}
+
+	if *explain != "" {
+		found := false
+		for _, l := range leads {
+			if l.ABN != *explain {
+				continue
+			}
+			found = true
+			fmt.Printf("\n%s (%s): %.3f\n", l.Name, l.ABN, m.Predict(&l, now))
+			fmt.Printf("  %-40s %8.3f\n", "(intercept)", m.Intercept)
+			for _, c := range m.Explain(&l, now) {
+				if c.LogOdds != 0 {
+					fmt.Printf("  %-40s %+8.3f  (value %g)\n", c.Feature, c.LogOdds, c.Value)
+				}
+			}
+		}
+		if !found {
+			logger.Error("No lead with that ABN", "abn", *explain)
+			os.Exit(1)
+		}
+	}
+
+	if *scoreLeads {
+		scores := make(map[string]float64)
+		for _, l := range leads {
+			if _, reviewed := labels[l.ABN]; reviewed {
+				continue
+			}
+			scores[l.ABN] = m.Predict(&l, now)
+		}
+		if err := repo.SetRankScores(ctx, m.ID, scores); err != nil {
+			logger.Error("Failed to save rank scores", "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Scored unreviewed leads", "model", m.ID, "count", len(scores))
+	}
+}
+
+func printCoefficients(m *rank.Model) {
+	fmt.Printf("\nModel %s, trained %s on %d leads (%d progressed)\n", m.ID, m.TrainedAt.Format("2006-01-02 15:04"), m.Samples, m.Positives)
+	fmt.Printf("Weights are log-odds per standard deviation of the feature.\n\n")
+	fmt.Printf("  %-40s %8.3f\n", "(intercept)", m.Intercept)
+	for _, f := range m.Coefficients() {
+		fmt.Printf("  %-40s %+8.3f  (mean %.3g, sd %.3g)\n", f.Name, f.Weight, f.Mean, f.Std)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database to restore into: a DuckDB file path, or sqlite:path for SQLite")
+	from := flag.String("from", "", "Backup directory to restore, e.g. out/backups/20261018T090000 (see backup -list)")
+	force := flag.Bool("force", false, "Restore over an existing database, which is moved aside to <path>.pre-restore-<time> once the restore succeeds")
+	flag.Parse()
+
+	if *from == "" {
+		fmt.Fprintf(os.Stderr, "Error: -from is required\n")
+		os.Exit(1)
+	}
+	_, path, err := storage.ParseDSN(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	m, err := storage.ReadBackupManifest(*from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	if _, err := os.Stat(path); err == nil && !*force {
+		fmt.Fprintf(os.Stderr, "Error: %s exists; use -force to move it aside and restore\n", path)
+		os.Exit(1)
+	}
+
+	aside, err := storage.RestoreFile(context.Background(), *dbPath, *from, logger)
+	if err != nil {
+		logger.Error("Restore failed", "from", *from, "err", err)
+		os.Exit(1)
+	}
+	if aside != "" {
+		logger.Info("Moved the existing database aside", "path", aside)
+	}
+	logger.Info("Restored", "from", *from, "taken", m.CreatedAt.Local().Format("2006-01-02 15:04"), "leads", m.Leads)
+}
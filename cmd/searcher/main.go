@@ -3,26 +3,179 @@ package main
 import (
 	"context"
 	"flag"
-	"fmt"
 	"log/slog"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/shanehull/sourcerer/internal/export"
+	"github.com/shanehull/sourcerer/internal/geo"
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/score"
+	"github.com/shanehull/sourcerer/internal/source"
 	"github.com/shanehull/sourcerer/internal/storage"
 )
 
 func main() {
-	dbPath := flag.String("db", "sourcing.duckdb", "Path to DuckDB file")
+	dbPath := flag.String("db", "sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
 	name := flag.String("name", "", "Search by name (case-insensitive contains)")
+	abn := flag.String("abn", "", "Only these ABNs (comma-separated)")
 	states := flag.String("states", "", "Filter by states (e.g. VIC,NSW)")
 	minAge := flag.Int("age", 0, "Minimum business age in years")
-	outPath := flag.String("out", "out/search_results.csv", "Output CSV path")
+	maxAge := flag.Int("max-age", 0, "Maximum business age in years")
+	sources := flag.String("sources", "", "Only leads listed by these sources (e.g. rto,northlink or NorthLink-FoodMfg)")
+	category := flag.String("category", "", "Only leads in this source category (e.g. Manufacturing)")
+	entityType := flag.String("entity-type", "", "Only these entity type codes or kinds, e.g. PRV or private-company,partnership")
+	currentOnly := flag.Bool("current", false, "Only entities the ABR lists as current")
+	rejected := flag.Bool("rejected", false, "Only leads the pipeline last rejected; the rejected_by column says why")
+	updatedSince := flag.String("updated-since", "", "Only leads saved on or after this date (YYYY-MM-DD)")
+	dealStatus := flag.String("deal-status", "", "Only leads at these deal statuses (e.g. contacted,meeting)")
+	owners := flag.String("owner", "", "Only deals owned by these people (comma-separated)")
+	due := flag.String("due", "", "Only leads whose next action is due by this date (2026-11-30) or within this duration (7d)")
+	tags := flag.String("tags", "", "Only leads carrying any of these tags (comma-separated)")
+	inList := flag.String("in-list", "", "Only leads on any of these lists (comma-separated, e.g. \"Q3 shortlist\")")
+	sortBy := flag.String("sort", "score", "Sort by score, age, name, updated or next-action")
+	scoreConfig := flag.String("score-config", "", "Scoring weights JSON to use instead of the bundled weights; match what sourcerer runs with")
+	limit := flag.Int("limit", 0, "Return at most this many leads (0 for all)")
+	outPath := flag.String("out", "", "Output path; its extension picks the format unless -format is set (default out/search_results.csv)")
+	formatFlag := flag.String("format", "", "Output format: csv, jsonl, parquet or xlsx")
+	columnsRaw := flag.String("columns", "", "Output only these columns, in this order (comma-separated, e.g. abn,name,state,score)")
+	profileName := flag.String("profile", "", "Output for import elsewhere instead: hubspot, salesforce, pipedrive or vcard")
+	sinceExport := flag.String("since-export", "", "Output only leads new or changed since the last export under this name (e.g. weekly-bd), then move its watermark on")
+	near := flag.String("near", "", "Center for -radius: a postcode (3175) or lat,long (-37.98,145.21)")
+	radiusKm := flag.Float64("radius", 0, "Only leads within this many km of -near; leads whose postcode the postcode data lacks are left out")
 	flag.Parse()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
-	repo, err := storage.NewDuckDBRepo(*dbPath, logger)
+	if *sinceExport != "" && *profileName != "" {
+		logger.Error("-since-export can't be combined with -profile")
+		os.Exit(1)
+	}
+	var profile export.Profile
+	if *profileName != "" {
+		var err error
+		if profile, err = export.ProfileByName(*profileName); err != nil {
+			logger.Error("Invalid -profile", "error", err)
+			os.Exit(1)
+		}
+	}
+	if *outPath == "" {
+		ext := *formatFlag
+		switch {
+		case profile != nil:
+			ext = profile.Ext()
+		case ext == "":
+			ext = "csv"
+		}
+		*outPath = "out/search_results." + strings.ToLower(ext)
+	}
+	format := ""
+	if profile == nil {
+		var err error
+		if format, err = export.Format(*formatFlag, *outPath); err != nil {
+			logger.Error("Invalid output format", "error", err)
+			os.Exit(1)
+		}
+	}
+	columns, err := storage.ParseExportColumns(*columnsRaw)
+	if err != nil {
+		logger.Error("Invalid -columns", "error", err)
+		os.Exit(1)
+	}
+
+	q := storage.LeadQuery{
+		NameContains: *name,
+		MinAge:       *minAge,
+		MaxAge:       *maxAge,
+		Category:     *category,
+		GST:          storage.Bool(true),
+		Limit:        *limit,
+	}
+	if *abn != "" {
+		q.ABNs = strings.Split(*abn, ",")
+	}
+	if *states != "" {
+		q.States = strings.Split(*states, ",")
+	}
+	if *sources != "" {
+		names, err := source.ResolveNames(strings.Split(*sources, ","))
+		if err != nil {
+			logger.Error("Invalid -sources", "error", err)
+			os.Exit(1)
+		}
+		q.Sources = names
+	}
+	if *entityType != "" {
+		codes, err := model.ParseEntityTypes(*entityType)
+		if err != nil {
+			logger.Error("Invalid -entity-type", "error", err)
+			os.Exit(1)
+		}
+		q.EntityTypes = codes
+	}
+	if *currentOnly {
+		q.Current = storage.Bool(true)
+	}
+	if *rejected {
+		q.Rejected = storage.Bool(true)
+	}
+	if *updatedSince != "" {
+		since, err := time.ParseInLocation("2006-01-02", *updatedSince, time.Local)
+		if err != nil {
+			logger.Error("Invalid -updated-since", "error", err)
+			os.Exit(1)
+		}
+		q.UpdatedSince = since
+	}
+	if q.DealStatuses, err = model.ParseDealStatuses(*dealStatus); err != nil {
+		logger.Error("Invalid -deal-status", "error", err)
+		os.Exit(1)
+	}
+	if *owners != "" {
+		q.Owners = strings.Split(*owners, ",")
+	}
+	if q.DueBy, err = model.ParseExpiry(*due, time.Now()); err != nil {
+		logger.Error("Invalid -due", "error", err)
+		os.Exit(1)
+	}
+	q.Tags = model.ParseTags(*tags)
+	if *inList != "" {
+		for _, l := range strings.Split(*inList, ",") {
+			q.Lists = append(q.Lists, strings.TrimSpace(l))
+		}
+	}
+	sort, err := storage.ParseSort(*sortBy)
+	if err != nil {
+		logger.Error("Invalid -sort", "error", err)
+		os.Exit(1)
+	}
+	q.Sort = sort
+
+	scoreWeights := score.Default()
+	if *scoreConfig != "" {
+		scoreWeights, err = score.LoadFile(*scoreConfig)
+		if err != nil {
+			logger.Error("Failed to load score config", "error", err)
+			os.Exit(1)
+		}
+	}
+	scorer := score.NewScorer(scoreWeights)
+
+	if *near != "" || *radiusKm > 0 {
+		if *near == "" || *radiusKm <= 0 {
+			logger.Error("-near and -radius must be used together")
+			os.Exit(1)
+		}
+		center, err := geo.Default().ParsePoint(*near)
+		if err != nil {
+			logger.Error("Invalid -near", "error", err)
+			os.Exit(1)
+		}
+		q.Radius = &model.Radius{Center: center, Km: *radiusKm}
+	}
+
+	repo, err := storage.Open(*dbPath, logger)
 	if err != nil {
 		logger.Error("Failed to connect to DB", "error", err)
 		os.Exit(1)
@@ -30,26 +183,38 @@ func main() {
 	defer repo.Close()
 
 	ctx := context.Background()
-
-	where := []string{"gst_registered = TRUE"}
-	if *name != "" {
-		where = append(where, fmt.Sprintf("lower(name) LIKE '%%%s%%'", strings.ToLower(*name)))
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "error", err)
+		os.Exit(1)
 	}
-	if *states != "" {
-		states_split := strings.Join(strings.Split(strings.ToUpper(*states), ","), "','")
-		where = append(where, fmt.Sprintf("state IN ('%s')", states_split))
+
+	// Scores go stale when the weights change or a day passes, and the
+	// default sort is by score
+	scored, err := repo.Rescore(ctx, scorer.Hash(), scorer.Score)
+	if err != nil {
+		logger.Error("Scoring failed", "error", err)
+		os.Exit(1)
 	}
-	if *minAge > 0 {
-		cutoff := time.Now().AddDate(-*minAge, 0, 0).Format("2006-01-02")
-		where = append(where, fmt.Sprintf("registration_date <= '%s'", cutoff))
+	if scored > 0 {
+		logger.Info("Scored leads", "count", scored, "weights", scorer.Hash())
 	}
 
-	query := fmt.Sprintf("COPY (SELECT * FROM leads WHERE %s) TO '%s' (HEADER, DELIMITER ',');",
-		strings.Join(where, " AND "),
-		*outPath,
-	)
-
-	_, err = repo.GetDB().ExecContext(ctx, query)
+	switch {
+	case profile != nil:
+		err = export.LeadsProfile(ctx, repo, *outPath, profile, q)
+	case *sinceExport != "":
+		var counts map[model.ExportChange]int
+		if counts, err = export.Delta(ctx, repo, *outPath, format, q, columns, *sinceExport); err == nil {
+			logger.Info("Delta",
+				"watermark", *sinceExport,
+				"new", counts[model.ExportNew],
+				"updated", counts[model.ExportUpdated],
+				"reactivated", counts[model.ExportReactivated],
+				"dropped", counts[model.ExportDropped])
+		}
+	default:
+		err = export.Leads(ctx, repo, *outPath, format, q, columns)
+	}
 	if err != nil {
 		logger.Error("Search failed", "error", err)
 		os.Exit(1)
@@ -7,29 +7,44 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/shanehull/sourcerer/internal/anzsic"
 	"github.com/shanehull/sourcerer/internal/enrich"
+	"github.com/shanehull/sourcerer/internal/export"
+	"github.com/shanehull/sourcerer/internal/geo"
 	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/precedence"
+	"github.com/shanehull/sourcerer/internal/score"
 	"github.com/shanehull/sourcerer/internal/source"
 	"github.com/shanehull/sourcerer/internal/storage"
 )
 
-func generateCSVPath(sources, states string, age int, outDir string) string {
+func generateExportPath(sources, states string, age int, outDir, ext string) string {
 	filename := fmt.Sprintf("sources-%s-min-age-%d", sources, age)
 	if states != "" {
 		statesHyphenated := strings.ReplaceAll(states, ",", "-")
 		filename += "-states-" + statesHyphenated
 	}
-	filename += "-" + time.Now().Format("20060102") + ".csv"
+	filename += "-" + time.Now().Format("20060102") + "." + ext
 	return filepath.Join(outDir, filename)
 }
 
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(raw string) []string {
+	var out []string
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
 type stats struct {
-	Found, Selected, New, Updated, Skipped, Error int
+	Found, Selected, Rejected, New, Updated, Cached, Refreshed, Skipped, Suppressed, Error int
 	mu                                           sync.Mutex
 }
 
@@ -45,8 +60,16 @@ func (s *stats) incr(field string, n int) {
 		s.New += n
 	case "Updated":
 		s.Updated += n
+	case "Rejected":
+		s.Rejected += n
+	case "Cached":
+		s.Cached += n
+	case "Refreshed":
+		s.Refreshed += n
 	case "Skipped":
 		s.Skipped += n
+	case "Suppressed":
+		s.Suppressed += n
 	case "Error":
 		s.Error += n
 	}
@@ -54,12 +77,37 @@ func (s *stats) incr(field string, n int) {
 
 func main() {
 	targetAge := flag.Int("age", 15, "Minimum business age")
-	dbPath := flag.String("db", "out/sourcing.duckdb", "Path to DuckDB file")
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
 	statesRaw := flag.String("states", "", "States filter (comma-separated)")
-	postcodesRaw := flag.String("postcodes", "", "Postcode ranges")
+	postcodesRaw := flag.String("postcodes", "", "Postcode ranges (e.g. 3000-3999,5095)")
+	lgasRaw := flag.String("lgas", "", "LGA filter (comma-separated, e.g. Greater Dandenong,Hume); leads whose postcode the postcode data lacks are left out")
+	regionsRaw := flag.String("regions", "", "SA4 region filter (comma-separated, e.g. Melbourne - South East); leads whose postcode the postcode data lacks are left out")
+	postcodeData := flag.String("postcode-data", "", "Postcode dataset CSV to use instead of the bundled one")
+	near := flag.String("near", "", "Center for -radius: a postcode (3175) or lat,long (-37.98,145.21)")
+	radiusKm := flag.Float64("radius", 0, "Only leads within this many km of -near; leads whose postcode the postcode data lacks are left out")
+	inspectSites := flag.Bool("websites", true, "Inspect lead websites for digital-maturity signals; leads are inspected once they pass every other criterion, including stored leads a relaxed criterion lets in when exporting on website filters")
+	cacheMaxAge := flag.Duration("cache-max-age", 30*24*time.Hour, "Re-enrich stored leads the ABR last confirmed longer ago than this (0 re-enriches every lead)")
+	siteStaleBefore := flag.Int("site-stale-before", 0, "Only leads whose website copyright year is before this year")
+	sitePlatforms := flag.String("site-platforms", "", "Only leads whose website runs on one of these platforms (e.g. wordpress,wix)")
+	siteNoHTTPS := flag.Bool("site-no-https", false, "Only leads whose website isn't served over HTTPS")
+	industriesRaw := flag.String("industries", "", "ANZSIC division, subdivision or class codes (e.g. C,22,8101)")
+	anzsicData := flag.String("anzsic-data", "", "ANZSIC taxonomy CSV to use instead of the bundled one")
+	minIncome := flag.Int64("min-income", 0, "Only leads whose latest ATO-reported total income is at least this ($)")
+	maxIncome := flag.Int64("max-income", 100_000_000, "Exclude leads whose latest ATO-reported total income is over this ($, 0 for no limit)")
+	dealStatusRaw := flag.String("deal-status", "", "Only export leads at these deal statuses (e.g. new,researching)")
+	ownersRaw := flag.String("owner", "", "Only export deals owned by these people (comma-separated)")
+	tagsRaw := flag.String("tags", "", "Only export leads carrying any of these tags (comma-separated)")
+	listsRaw := flag.String("in-list", "", "Only export leads on any of these lists (comma-separated)")
+	dueRaw := flag.String("due", "", "Only export leads whose next action is due by this date (2026-11-30) or within this duration (7d)")
+	scoreConfig := flag.String("score-config", "", "Scoring weights JSON to use instead of the bundled weights")
+	precedenceConfig := flag.String("precedence", "", "Field precedence rules JSON to use instead of the bundled rules")
 	sourcesFlag := flag.String("sources", "rto,amtil,semma,northlink,hobsonsbay,abr", "Sources to run")
 	keywordsRaw := flag.String("keywords", "", "ABR search keywords")
-	outDir := flag.String("outdir", "out", "Output directory for CSV and database")
+	outDir := flag.String("outdir", "out", "Output directory for the export and database")
+	formatFlag := flag.String("format", "csv", "Export format: csv, jsonl, parquet or xlsx")
+	columnsRaw := flag.String("columns", "", "Export only these columns, in this order (comma-separated, e.g. abn,name,state,score)")
+	profileName := flag.String("profile", "", "Export for import elsewhere instead: hubspot, salesforce, pipedrive or vcard")
+	sinceExport := flag.String("since-export", "", "Export only leads new or changed since the last export under this name (e.g. weekly-bd), then move its watermark on")
 	debug := flag.Bool("debug", false, "Enable debug logs")
 	exportOnly := flag.Bool("export-only", false, "Only export existing data, skip scraping")
 	flag.Parse()
@@ -70,11 +118,40 @@ func main() {
 		os.Exit(1)
 	}
 
-	outPath := generateCSVPath(
+	format, err := export.Format(*formatFlag, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	columns, err := storage.ParseExportColumns(*columnsRaw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid -columns: %v\n", err)
+		os.Exit(1)
+	}
+
+	ext := format
+	if *sinceExport != "" {
+		if *profileName != "" {
+			fmt.Fprintf(os.Stderr, "Error: -since-export can't be combined with -profile\n")
+			os.Exit(1)
+		}
+		ext = "since-" + *sinceExport + "." + format
+	}
+	var profile export.Profile
+	if *profileName != "" {
+		if profile, err = export.ProfileByName(*profileName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		ext = strings.ToLower(*profileName) + "." + profile.Ext()
+	}
+
+	outPath := generateExportPath(
 		strings.ReplaceAll(*sourcesFlag, ",", "-"),
 		strings.ToLower(*statesRaw),
 		*targetAge,
 		*outDir,
+		ext,
 	)
 
 	logLevel := slog.LevelInfo
@@ -88,17 +165,97 @@ func main() {
 		allowedStates = strings.Split(strings.ToUpper(*statesRaw), ",")
 	}
 
-	var allowedPostcodes []model.PostcodeRange
-	if *postcodesRaw != "" {
-		for _, r := range strings.Split(*postcodesRaw, ",") {
-			before, after, found := strings.Cut(r, "-")
-			if found {
-				min, _ := strconv.Atoi(before)
-				max, _ := strconv.Atoi(after)
-				allowedPostcodes = append(allowedPostcodes, model.PostcodeRange{Min: min, Max: max})
-			}
+	allowedPostcodes, err := model.ParsePostcodeRanges(*postcodesRaw)
+	if err != nil {
+		logger.Error("Invalid postcodes", "err", err)
+		os.Exit(1)
+	}
+
+	allowedLGAs := splitList(*lgasRaw)
+	allowedRegions := splitList(*regionsRaw)
+
+	geoData := geo.Default()
+	if *postcodeData != "" {
+		geoData, err = geo.LoadFile(*postcodeData)
+		if err != nil {
+			logger.Error("Failed to load postcode data", "err", err)
+			os.Exit(1)
+		}
+	}
+
+	var radius *model.Radius
+	if *radiusKm > 0 || *near != "" {
+		if *radiusKm <= 0 || *near == "" {
+			logger.Error("-near and -radius must be used together")
+			os.Exit(1)
+		}
+		center, err := geoData.ParsePoint(*near)
+		if err != nil {
+			logger.Error("Invalid -near", "err", err)
+			os.Exit(1)
+		}
+		radius = &model.Radius{Center: center, Km: *radiusKm}
+	}
+
+	websiteFilter := model.WebsiteFilter{
+		StaleBefore: *siteStaleBefore,
+		Platforms:   splitList(*sitePlatforms),
+		NoHTTPS:     *siteNoHTTPS,
+	}
+
+	allowedIndustries := splitList(strings.ToUpper(*industriesRaw))
+
+	taxonomy := anzsic.Default()
+	if *anzsicData != "" {
+		taxonomy, err = anzsic.LoadFile(*anzsicData)
+		if err != nil {
+			logger.Error("Failed to load ANZSIC data", "err", err)
+			os.Exit(1)
 		}
 	}
+	for _, code := range allowedIndustries {
+		if _, ok := taxonomy.Lookup(code); !ok {
+			logger.Error("Unknown ANZSIC code", "code", code)
+			os.Exit(1)
+		}
+	}
+
+	dealStatuses, err := model.ParseDealStatuses(*dealStatusRaw)
+	if err != nil {
+		logger.Error("Invalid -deal-status", "err", err)
+		os.Exit(1)
+	}
+	dueBy, err := model.ParseExpiry(*dueRaw, time.Now())
+	if err != nil {
+		logger.Error("Invalid -due", "err", err)
+		os.Exit(1)
+	}
+
+	exportSources, err := source.ResolveNames(strings.Split(*sourcesFlag, ","))
+	if err != nil {
+		logger.Error("Invalid -sources", "err", err)
+		os.Exit(1)
+	}
+
+	scoreWeights := score.Default()
+	if *scoreConfig != "" {
+		scoreWeights, err = score.LoadFile(*scoreConfig)
+		if err != nil {
+			logger.Error("Failed to load score config", "err", err)
+			os.Exit(1)
+		}
+	}
+	scorer := score.NewScorer(scoreWeights)
+
+	precedenceRules := precedence.Default()
+	if *precedenceConfig != "" {
+		precedenceRules, err = precedence.LoadFile(*precedenceConfig)
+		if err != nil {
+			logger.Error("Failed to load precedence config", "err", err)
+			os.Exit(1)
+		}
+	}
+	resolver := precedence.NewResolver(precedenceRules)
 
 	apiKey := os.Getenv("ABR_GUID")
 	if apiKey == "" {
@@ -106,22 +263,96 @@ func main() {
 		os.Exit(1)
 	}
 
-	repo, err := storage.NewDuckDBRepo(*dbPath, logger)
+	repo, err := storage.Open(*dbPath, logger)
 	if err != nil {
 		logger.Error("DB connection failed", "err", err)
 		os.Exit(1)
 	}
 	defer repo.Close()
 	ctx := context.Background()
-	repo.Init(ctx)
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+	runID := storage.NewRunID(time.Now())
+	repo.SetRunID(runID)
+	logger = logger.With("run", runID)
 
 	enricher := enrich.NewABRClient(apiKey, logger)
+	geoEnricher := enrich.NewGeoEnricher(geoData)
+	charityEnricher := enrich.NewCharityEnricher(repo)
+	taxEnricher := enrich.NewTaxEnricher(repo)
+	websiteEnricher := enrich.NewWebsiteEnricher(logger)
+	industryClassifier := enrich.NewIndustryClassifier(taxonomy)
+
+	// Leads saved before locations existed, or with a postcode an earlier
+	// dataset lacked
+	located, err := repo.LocateUnlocated(ctx, geoData)
+	if err != nil {
+		logger.Error("Failed to locate stored leads", "err", err)
+	} else if located > 0 {
+		logger.Info("Located stored leads", "count", located)
+	}
+
+	// Leads saved before classification existed
+	classified, err := repo.ClassifyUnclassified(ctx, func(l *model.Lead) { industryClassifier.Enrich(ctx, l) })
+	if err != nil {
+		logger.Error("Failed to classify stored leads", "err", err)
+	} else if classified > 0 {
+		logger.Info("Classified stored leads", "count", classified)
+	}
+
+	exportQuery := storage.LeadQuery{
+		MinAge:       *targetAge,
+		States:       allowedStates,
+		Sources:      exportSources,
+		Postcodes:    allowedPostcodes,
+		LGAs:         allowedLGAs,
+		Regions:      allowedRegions,
+		Radius:       radius,
+		Private:      storage.Bool(true),
+		GST:          storage.Bool(true),
+		Current:      storage.Bool(true),
+		Website:      websiteFilter,
+		Industries:   allowedIndustries,
+		MinIncome:    *minIncome,
+		MaxIncome:    *maxIncome,
+		DealStatuses: dealStatuses,
+		Owners:       splitList(*ownersRaw),
+		DueBy:        dueBy,
+		Tags:         model.ParseTags(*tagsRaw),
+		Lists:        splitList(*listsRaw),
+		Sort:         storage.SortScore,
+	}
+
+	writeExport := func() error {
+		switch {
+		case profile != nil:
+			return export.LeadsProfile(ctx, repo, outPath, profile, exportQuery)
+		case *sinceExport != "":
+			counts, err := export.Delta(ctx, repo, outPath, format, exportQuery, columns, *sinceExport)
+			if err != nil {
+				return err
+			}
+			logger.Info("Delta export",
+				"watermark", *sinceExport,
+				"new", counts[model.ExportNew],
+				"updated", counts[model.ExportUpdated],
+				"reactivated", counts[model.ExportReactivated],
+				"dropped", counts[model.ExportDropped])
+			return nil
+		}
+		return export.Leads(ctx, repo, outPath, format, exportQuery, columns)
+	}
 
 	// Export-only mode: skip scraping and go straight to export
 	if *exportOnly {
 		logger.Info("Export-only mode enabled, exporting existing data")
-		requestedSources := strings.Split(strings.ToUpper(*sourcesFlag), ",")
-		if err := repo.ExportCSV(ctx, outPath, *targetAge, allowedStates, requestedSources); err != nil {
+		if *inspectSites {
+			inspectUninspected(ctx, logger, repo, websiteEnricher, exportQuery)
+		}
+		rescore(ctx, logger, repo, scorer)
+		if err := writeExport(); err != nil {
 			logger.Error("Export failed", "err", err)
 		} else {
 			logger.Info("Export successful", "path", outPath)
@@ -129,6 +360,12 @@ func main() {
 		return
 	}
 
+	suppressions, err := repo.ListSuppressions(ctx, false)
+	if err != nil {
+		logger.Error("Failed to load suppressions", "err", err)
+		os.Exit(1)
+	}
+
 	var sources []source.Sourcer
 	for _, s := range strings.Split(*sourcesFlag, ",") {
 		s := strings.TrimSpace(strings.ToLower(s))
@@ -143,17 +380,9 @@ func main() {
 			srcLogger := logger.With("source", "SEMMA")
 			sources = append(sources, source.NewSEMMAScraper(srcLogger))
 		case "northlink":
-			for _, spec := range []struct {
-				url  string
-				cat  string
-				name string
-			}{
-				{"https://northlink.org.au/melbournes-north-food-group/manufacturer-directory/", "Manufacturing", "NorthLink-FoodMfg"},
-				{"https://northlink.org.au/melbournes-north-food-group/service-provider-directory/", "Service Provider", "NorthLink-FoodSvc"},
-				{"https://northlink.org.au/melbournes-north-advanced-manufacturing-group/partner-directory/", "Manufacturing", "NorthLink-MfgPartner"},
-			} {
-				srcLogger := logger.With("source", spec.name)
-				sources = append(sources, source.NewNorthLinkScraper(srcLogger, spec.url, spec.cat, spec.name))
+			for _, dir := range source.NorthLinkDirectories {
+				srcLogger := logger.With("source", dir.Name)
+				sources = append(sources, source.NewNorthLinkScraper(srcLogger, dir.URL, dir.Category, dir.Name))
 			}
 		case "hobsonsbay":
 			srcLogger := logger.With("source", "HobsonsBay")
@@ -211,46 +440,148 @@ func main() {
 				continue
 			}
 
-			// Check Cache
-			existing, _ := repo.GetLeadByName(ctx, lead.Name)
+			// Record this source's listing before the cache replaces the lead
+			listing := model.SourceRecord{
+				Source:     result.source.Name(),
+				FoundAtURL: lead.FoundAtURL,
+				Category:   lead.Category,
+				RawName:    lead.Name,
+			}
+
+			// Don't spend lookups on leads we've ruled out
+			if sup, ok := suppressions.Match(lead.ABN, lead.Name, listing.Source, time.Now()); ok {
+				s.incr("Suppressed", 1)
+				srcLogger.Debug("Suppressed", "name", lead.Name, "abn", lead.ABN, "suppression", sup.ID, "reason", sup.Reason)
+				continue
+			}
+
+			lead.AssertFields(listing.Source, time.Now())
+			asserted := lead.Assertions
+
+			// Check the cache, re-enriching entries the ABR hasn't confirmed
+			// within -cache-max-age
+			existing, err := repo.LookupLead(ctx, lead.ABN, lead.Name)
+			if err != nil {
+				srcLogger.Debug("Cache lookup failed (non-fatal)", "name", lead.Name, "abn", lead.ABN, "err", err)
+			}
+			enrichLead := existing == nil
 			if existing != nil {
+				keyword, foundAt := lead.SearchKeyword, lead.FoundAtURL
 				lead = *existing
-			} else {
+				lead.SearchKeyword = keyword
+				lead.FoundAtURL = foundAt
+				lead.Assertions = asserted
+				if *cacheMaxAge > 0 && time.Since(lead.EnrichedAt) <= *cacheMaxAge {
+					s.incr("Cached", 1)
+				} else {
+					s.incr("Refreshed", 1)
+					enrichLead = true
+				}
+			}
+			if enrichLead {
 				// Try to enrich, but don't fail if enrichment fails
 				if err := enricher.Enrich(ctx, &lead); err != nil {
 					srcLogger.Debug("Enrichment failed (non-fatal)", "name", lead.Name, "abn", lead.ABN, "err", err)
 					// Continue processing - enrichment is optional
+				} else {
+					lead.EnrichedAt = time.Now()
+				}
+			}
+			lead.Sources = []string{listing.Source}
+			lead.Provenance = []model.SourceRecord{listing}
+
+			// The ABN and registered name are only known now
+			if sup, ok := suppressions.Match(lead.ABN, lead.Name, listing.Source, time.Now()); ok {
+				s.incr("Suppressed", 1)
+				srcLogger.Debug("Suppressed", "name", lead.Name, "abn", lead.ABN, "suppression", sup.ID, "reason", sup.Reason)
+				continue
+			}
+
+			// Settle conflicting values from this and earlier runs by precedence
+			var stored []model.FieldValue
+			if lead.ABN != "" {
+				if stored, err = repo.GetFieldValues(ctx, lead.ABN); err != nil {
+					srcLogger.Debug("Field value lookup failed (non-fatal)", "name", lead.Name, "abn", lead.ABN, "err", err)
 				}
 			}
+			resolver.Apply(&lead, stored)
+
+			geoEnricher.Enrich(ctx, &lead)
+			if err := charityEnricher.Enrich(ctx, &lead); err != nil {
+				srcLogger.Debug("Charity lookup failed (non-fatal)", "name", lead.Name, "abn", lead.ABN, "err", err)
+			}
+			if err := taxEnricher.Enrich(ctx, &lead); err != nil {
+				srcLogger.Debug("Tax transparency lookup failed (non-fatal)", "name", lead.Name, "abn", lead.ABN, "err", err)
+			}
+			if lead.StateMismatch {
+				srcLogger.Debug("State does not match postcode", "name", lead.Name, "state", lead.State, "postcode", lead.Postcode)
+			}
+			if !lead.Located() && (len(allowedLGAs) > 0 || len(allowedRegions) > 0 || radius != nil) {
+				srcLogger.Warn("Postcode not in the postcode data, so area and radius filters leave it out", "name", lead.Name, "postcode", lead.Postcode)
+			}
 
-			// Core Filter Logic - only allow if enriched and meets criteria
-			isVet := lead.IsVeteran(*targetAge)
-			isInv := lead.IsInvestable(allowedStates, allowedPostcodes)
-			isGst := lead.IsGSTRegistered
-			isPrivate := lead.IsPrivateEntity()
+			// Test every criterion we can, and keep the results with the lead
+			// so exports can select on them without a re-scrape
+			criteria := model.CriteriaResults{
+				model.CriterionAge:      lead.IsVeteran(*targetAge),
+				model.CriterionCurrent:  lead.IsCurrentEntity,
+				model.CriterionGST:      lead.IsGSTRegistered && !lead.GSTEffectiveFrom.IsZero(),
+				model.CriterionLocation: lead.InLocation(allowedStates, allowedPostcodes) && lead.InAreas(allowedLGAs, allowedRegions) && lead.WithinRadius(radius),
+				model.CriterionPrivate:  lead.IsPrivateEntity(),
+				model.CriterionIncome:   lead.WithinIncome(*minIncome, *maxIncome),
+			}
 
-			if isVet && isInv && isGst && isPrivate {
+			// Only spend a website fetch on leads that otherwise qualify. Ones
+			// rejected here are inspected at export if a relaxed criterion
+			// lets them in
+			if criteria.Passed() {
+				if *inspectSites {
+					if err := websiteEnricher.Enrich(ctx, &lead); err != nil {
+						srcLogger.Debug("Website inspection failed (non-fatal)", "name", lead.Name, "url", lead.BusinessURL, "err", err)
+					}
+				}
+				resolver.Apply(&lead, stored)
+				criteria[model.CriterionWebsite] = lead.MatchesWebsite(websiteFilter)
+			}
+
+			// Classify once the homepage text is in hand, if it will be
+			industryClassifier.Enrich(ctx, &lead)
+			criteria[model.CriterionIndustry] = lead.InIndustries(allowedIndustries)
+			lead.Criteria = criteria
+
+			// Without an ABN there's nothing to key the lead on
+			if lead.ABN == "" {
+				s.incr("Skipped", 1)
+				srcLogger.Debug("Skipped without ABN", "name", lead.Name)
+				continue
+			}
+
+			isNew, err := repo.SaveLead(ctx, lead)
+			if err != nil {
+				srcLogger.Error("Save failed", "name", lead.Name, "err", err)
+				s.incr("Error", 1)
+				continue
+			}
+			if isNew {
+				s.incr("New", 1)
+			} else {
+				s.incr("Updated", 1)
+			}
+			if criteria.Passed() {
 				s.incr("Selected", 1)
-				isNew, err := repo.SaveLead(ctx, lead)
-				if err != nil {
-					srcLogger.Error("Save failed", "name", lead.Name, "err", err)
-					s.incr("Error", 1)
-				} else if isNew {
-					s.incr("New", 1)
+				if isNew {
 					srcLogger.Info("Saved new", "name", lead.Name, "age", lead.AgeYears())
-				} else {
-					s.incr("Updated", 1)
 				}
 			} else {
-				s.incr("Skipped", 1)
-				srcLogger.Debug("Skipped", "name", lead.Name, "age", lead.AgeYears(), "vet", isVet, "inv", isInv, "gst", isGst, "private", isPrivate, "state", lead.State, "entity_type", lead.EntityType, "current", lead.IsCurrentEntity)
+				s.incr("Rejected", 1)
+				srcLogger.Debug("Rejected", "name", lead.Name, "rejected_by", criteria.RejectedBy(), "age", lead.AgeYears(), "charity", lead.IsCharityOrNFP(), "anzsic", lead.Industry.Code(), "state", lead.State, "postcode", lead.Postcode, "lga", lead.LGA, "entity_type", lead.EntityType, "current", lead.IsCurrentEntity)
 			}
 		}
 	}
 
 	// Count total qualified leads in database (after all inserts are done)
 	time.Sleep(100 * time.Millisecond) // Small delay to ensure DB is flushed
-	totalQualified, err := repo.CountQualified(ctx, *targetAge, allowedStates)
+	totalQualified, err := repo.CountLeads(ctx, exportQuery)
 	if err != nil {
 		logger.Error("Failed to count qualified leads", "err", err)
 		totalQualified = 0
@@ -261,13 +592,55 @@ func main() {
 		"selected", totalQualified,
 		"new", s.New,
 		"updated", s.Updated,
+		"rejected", s.Rejected,
+		"cached", s.Cached,
+		"refreshed", s.Refreshed,
 		"skipped", s.Skipped,
+		"suppressed", s.Suppressed,
 		"errors", s.Error)
 
-	requestedSources := strings.Split(strings.ToUpper(*sourcesFlag), ",")
-	if err := repo.ExportCSV(ctx, outPath, *targetAge, allowedStates, requestedSources); err != nil {
+	if *inspectSites {
+		inspectUninspected(ctx, logger, repo, websiteEnricher, exportQuery)
+	}
+	rescore(ctx, logger, repo, scorer)
+	if err := writeExport(); err != nil {
 		logger.Error("Export failed", "err", err)
 	} else {
 		logger.Info("Export successful", "path", outPath)
 	}
 }
+
+// inspectUninspected inspects the websites of stored leads the export would
+// select but for its website filters, and that have never been inspected:
+// leads rejected before a criterion was relaxed. Without their signals the
+// website filters would leave them out.
+func inspectUninspected(ctx context.Context, logger *slog.Logger, repo storage.Repository, websiteEnricher *enrich.WebsiteEnricher, q storage.LeadQuery) {
+	if q.Website.IsZero() {
+		return
+	}
+	n, err := repo.InspectUninspected(ctx, q, func(l *model.Lead) {
+		if err := websiteEnricher.Enrich(ctx, l); err != nil {
+			logger.Debug("Website inspection failed (non-fatal)", "name", l.Name, "url", l.BusinessURL, "err", err)
+		}
+	})
+	if err != nil {
+		logger.Error("Failed to inspect stored leads' websites", "err", err)
+		return
+	}
+	if n > 0 {
+		logger.Info("Inspected stored leads' websites", "count", n)
+	}
+}
+
+// rescore brings stored scores up to date with the current weights and with
+// leads saved this run, so the export sorts on fresh scores.
+func rescore(ctx context.Context, logger *slog.Logger, repo storage.Repository, scorer *score.Scorer) {
+	n, err := repo.Rescore(ctx, scorer.Hash(), scorer.Score)
+	if err != nil {
+		logger.Error("Scoring failed", "err", err)
+		return
+	}
+	if n > 0 {
+		logger.Info("Scored leads", "count", n, "weights", scorer.Hash())
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/source"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	abn := flag.String("abn", "", "ABN to suppress")
+	name := flag.String("name", "", "Name pattern to suppress, case-insensitive, * matches anything (e.g. \"*holdings*\")")
+	sourceFlag := flag.String("source", "", "Only suppress listings from this source, e.g. rto or NorthLink-FoodMfg (default all sources)")
+	reason := flag.String("reason", "", "Why the lead is suppressed")
+	author := flag.String("author", os.Getenv("USER"), "Who suppressed it")
+	expires := flag.String("expires", "", "When the suppression lapses: a date (2027-06-30) or a duration (90d); default never")
+	list := flag.Bool("list", false, "List suppressions")
+	all := flag.Bool("all", false, "With -list, include expired suppressions")
+	remove := flag.String("remove", "", "ID of a suppression to remove")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	if *list {
+		suppressions, err := repo.ListSuppressions(ctx, *all)
+		if err != nil {
+			logger.Error("Failed to list suppressions", "err", err)
+			os.Exit(1)
+		}
+		now := time.Now()
+		for _, s := range suppressions {
+			var match []string
+			if s.ABN != "" {
+				match = append(match, "abn="+s.ABN)
+			}
+			if s.NamePattern != "" {
+				match = append(match, "name="+s.NamePattern)
+			}
+			scope := s.Source
+			if scope == "" {
+				scope = "all sources"
+			}
+			expiry := "never"
+			if !s.ExpiresAt.IsZero() {
+				expiry = s.ExpiresAt.Format("2006-01-02")
+				if !s.Active(now) {
+					expiry += " (expired)"
+				}
+			}
+			fmt.Printf("%s  %s  %-30s  %-20s  expires %-20s  %s: %s\n", s.ID, s.CreatedAt.Format("2006-01-02"), strings.Join(match, " "), scope, expiry, s.Author, s.Reason)
+		}
+		return
+	}
+
+	if *remove != "" {
+		removed, err := repo.RemoveSuppression(ctx, *remove)
+		if err != nil {
+			logger.Error("Failed to remove suppression", "err", err)
+			os.Exit(1)
+		}
+		if !removed {
+			logger.Error("No suppression with that ID", "id", *remove)
+			os.Exit(1)
+		}
+		logger.Info("Removed suppression", "id", *remove)
+		return
+	}
+
+	if *abn == "" && *name == "" {
+		fmt.Fprintf(os.Stderr, "Error: -abn or -name is required\n")
+		os.Exit(1)
+	}
+	expiresAt, err := model.ParseExpiry(*expires, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// A key like northlink covers several sources, so suppress each
+	scopes := []string{""}
+	if *sourceFlag != "" {
+		if scopes, err = source.ResolveNames([]string{*sourceFlag}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	for _, scope := range scopes {
+		id, err := repo.AddSuppression(ctx, model.Suppression{
+			ABN:         strings.ReplaceAll(*abn, " ", ""),
+			NamePattern: strings.TrimSpace(*name),
+			Source:      scope,
+			Reason:      *reason,
+			Author:      *author,
+			ExpiresAt:   expiresAt,
+		})
+		if err != nil {
+			logger.Error("Failed to add suppression", "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Added suppression", "id", id, "abn", *abn, "name", *name, "source", scope)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/source"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+// filterFlags select the leads to tag
+var filterFlags = map[string]bool{
+	"abn": true, "name": true, "states": true, "sources": true, "category": true,
+	"age": true, "industries": true, "deal-status": true, "tagged": true, "in-list": true,
+}
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	add := flag.String("add", "", "Tags to add (comma-separated, e.g. \"q3-shortlist,family-owned\")")
+	remove := flag.String("remove", "", "Tags to remove (comma-separated)")
+	author := flag.String("author", os.Getenv("USER"), "Who tagged the leads")
+	list := flag.Bool("list", false, "List the tags in use and how many leads carry each")
+	abn := flag.String("abn", "", "Leads with these ABNs (comma-separated); alone, shows the lead's tags")
+	name := flag.String("name", "", "Leads whose name contains this (case-insensitive)")
+	states := flag.String("states", "", "Leads in these states (e.g. VIC,NSW)")
+	sourcesFlag := flag.String("sources", "", "Leads listed by these sources (e.g. rto,northlink)")
+	category := flag.String("category", "", "Leads in this source category (e.g. Manufacturing)")
+	age := flag.Int("age", 0, "Leads at least this many years old")
+	industries := flag.String("industries", "", "Leads in these ANZSIC divisions, subdivisions or classes (e.g. C,22)")
+	dealStatus := flag.String("deal-status", "", "Leads at these deal statuses (e.g. meeting)")
+	tagged := flag.String("tagged", "", "Leads that already carry any of these tags")
+	inList := flag.String("in-list", "", "Leads on any of these lists (comma-separated)")
+	flag.Parse()
+
+	hasFilters := false
+	flag.Visit(func(f *flag.Flag) {
+		if filterFlags[f.Name] {
+			hasFilters = true
+		}
+	})
+	if (*add != "" || *remove != "") && !hasFilters {
+		fmt.Fprintf(os.Stderr, "Error: at least one filter is required (-abn, -name, -states, -sources, -category, -age, -industries, -deal-status, -tagged or -in-list)\n")
+		os.Exit(1)
+	}
+	if !*list && *add == "" && *remove == "" && *abn == "" {
+		fmt.Fprintf(os.Stderr, "Error: one of -add, -remove, -list or -abn is required\n")
+		os.Exit(1)
+	}
+
+	var q storage.LeadQuery
+	var err error
+	if *abn != "" {
+		q.ABNs = strings.Split(*abn, ",")
+	}
+	q.NameContains = *name
+	if *states != "" {
+		q.States = strings.Split(*states, ",")
+	}
+	if *sourcesFlag != "" {
+		if q.Sources, err = source.ResolveNames(strings.Split(*sourcesFlag, ",")); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	q.Category = *category
+	q.MinAge = *age
+	if *industries != "" {
+		q.Industries = strings.Split(strings.ToUpper(*industries), ",")
+	}
+	if q.DealStatuses, err = model.ParseDealStatuses(*dealStatus); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	q.Tags = model.ParseTags(*tagged)
+	if *inList != "" {
+		for _, l := range strings.Split(*inList, ",") {
+			q.Lists = append(q.Lists, strings.TrimSpace(l))
+		}
+	}
+	if hasFilters && q.IsZero() {
+		fmt.Fprintf(os.Stderr, "Error: the filters given match every lead\n")
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	if *list {
+		tags, err := repo.ListTags(ctx)
+		if err != nil {
+			logger.Error("Failed to list tags", "err", err)
+			os.Exit(1)
+		}
+		for _, t := range tags {
+			fmt.Printf("%-30s  %5d leads\n", t.Tag, t.Leads)
+		}
+		return
+	}
+
+	if *add == "" && *remove == "" {
+		for _, a := range q.ABNs {
+			tags, err := repo.GetLeadTags(ctx, strings.TrimSpace(a))
+			if err != nil {
+				logger.Error("Failed to load tags", "err", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%s  %s\n", strings.TrimSpace(a), strings.Join(tags, ", "))
+		}
+		return
+	}
+
+	if *add != "" {
+		tags := model.ParseTags(*add)
+		added, err := repo.TagLeads(ctx, q, tags, *author)
+		if err != nil {
+			logger.Error("Tagging failed", "filters", q.String(), "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Tagged leads", "tags", strings.Join(tags, ","), "filters", q.String(), "added", added)
+	}
+	if *remove != "" {
+		tags := model.ParseTags(*remove)
+		removed, err := repo.UntagLeads(ctx, q, tags)
+		if err != nil {
+			logger.Error("Untagging failed", "filters", q.String(), "err", err)
+			os.Exit(1)
+		}
+		logger.Info("Untagged leads", "tags", strings.Join(tags, ","), "filters", q.String(), "removed", removed)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+func main() {
+	dbPath := flag.String("db", "out/sourcing.duckdb", "Database: a DuckDB file path, or sqlite:path for SQLite")
+	list := flag.Bool("list", false, "List the deletes that can be undone")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: undo [-db path] <batch>\n       undo [-db path] -list\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if !*list && flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	repo, err := storage.Open(*dbPath, logger)
+	if err != nil {
+		logger.Error("DB connection failed", "err", err)
+		os.Exit(1)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	if err := repo.Init(ctx); err != nil {
+		logger.Error("DB init failed", "err", err)
+		os.Exit(1)
+	}
+
+	if *list {
+		batches, err := repo.ListDeleteBatches(ctx)
+		if err != nil {
+			logger.Error("Failed to list deletes", "err", err)
+			os.Exit(1)
+		}
+		for _, b := range batches {
+			status := "can be undone"
+			if !b.RestoredAt.IsZero() {
+				status = "undone " + b.RestoredAt.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%s  %s  %5d leads  %-12s  %-22s  %s\n", b.ID, b.DeletedAt.Local().Format("2006-01-02 15:04"), b.Leads, b.Author, status, b.Filters)
+		}
+		return
+	}
+
+	batch := flag.Arg(0)
+	restored, skipped, err := repo.UndoDelete(ctx, batch)
+	if err != nil {
+		logger.Error("Undo failed", "batch", batch, "err", err)
+		os.Exit(1)
+	}
+	for _, abn := range skipped {
+		logger.Warn("Lead has been stored again since the delete, left as it is", "abn", abn)
+	}
+	logger.Info("Restored leads", "batch", batch, "restored", restored, "skipped", len(skipped))
+}
@@ -0,0 +1,160 @@
+package anzsic
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// anzsic.csv holds the ANZSIC 2006 divisions and subdivisions, plus the classes
+// of the industries we source from (all of Manufacturing, and the trade,
+// service and education classes our directories turn up). The full ABS class
+// list can be loaded at runtime with LoadFile.
+//
+// Rows are code,title,keywords. A division row (a letter) owns the subdivision
+// rows (2 digits) that follow it; classes (4 digits) belong to the subdivision
+// their code starts with. Groups (3 digits) are accepted and ignored. Keywords
+// are semicolon-separated phrases the classifier looks for.
+//
+//go:embed anzsic.csv
+var anzsicCSV []byte
+
+type Level int
+
+const (
+	LevelDivision Level = iota + 1
+	LevelSubdivision
+	LevelClass
+)
+
+type Entry struct {
+	Code     string
+	Title    string
+	Level    Level
+	Division string // Division letter, for every level
+	Keywords []string
+}
+
+// Subdivision returns the 2 digit subdivision code, or "" for a division.
+func (e Entry) Subdivision() string {
+	if e.Level == LevelDivision {
+		return ""
+	}
+	return e.Code[:2]
+}
+
+type Taxonomy struct {
+	entries []Entry
+	byCode  map[string]int
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultTaxonomy *Taxonomy
+)
+
+// Default returns the embedded taxonomy.
+func Default() *Taxonomy {
+	defaultOnce.Do(func() {
+		t, err := Load(bytes.NewReader(anzsicCSV))
+		if err != nil {
+			panic(fmt.Sprintf("anzsic: embedded taxonomy is invalid: %v", err))
+		}
+		defaultTaxonomy = t
+	})
+	return defaultTaxonomy
+}
+
+func LoadFile(path string) (*Taxonomy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open ANZSIC data: %w", err)
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+func Load(r io.Reader) (*Taxonomy, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+
+	t := &Taxonomy{byCode: make(map[string]int)}
+	subdivisionDivision := make(map[string]string)
+	division := ""
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 2 {
+			continue
+		}
+
+		code := strings.ToUpper(strings.TrimSpace(record[0]))
+		e := Entry{Code: code, Title: strings.TrimSpace(record[1])}
+		if len(record) > 2 {
+			for _, kw := range strings.Split(record[2], ";") {
+				if kw = strings.TrimSpace(kw); kw != "" {
+					e.Keywords = append(e.Keywords, kw)
+				}
+			}
+		}
+
+		switch {
+		case len(code) == 1 && code >= "A" && code <= "Z":
+			division = code
+			e.Level = LevelDivision
+			e.Division = code
+		case len(code) == 2:
+			if division == "" {
+				return nil, fmt.Errorf("subdivision %s listed before any division", code)
+			}
+			e.Level = LevelSubdivision
+			e.Division = division
+			subdivisionDivision[code] = division
+		case len(code) == 3:
+			continue
+		case len(code) == 4:
+			div, ok := subdivisionDivision[code[:2]]
+			if !ok {
+				return nil, fmt.Errorf("class %s has no subdivision %s", code, code[:2])
+			}
+			e.Level = LevelClass
+			e.Division = div
+		default:
+			return nil, fmt.Errorf("invalid ANZSIC code %q", code)
+		}
+
+		if _, dup := t.byCode[code]; dup {
+			return nil, fmt.Errorf("duplicate ANZSIC code %s", code)
+		}
+		t.byCode[code] = len(t.entries)
+		t.entries = append(t.entries, e)
+	}
+	return t, nil
+}
+
+// Lookup returns the entry for a division, subdivision or class code.
+func (t *Taxonomy) Lookup(code string) (Entry, bool) {
+	i, ok := t.byCode[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return Entry{}, false
+	}
+	return t.entries[i], true
+}
+
+func (t *Taxonomy) Entries() []Entry {
+	return t.entries
+}
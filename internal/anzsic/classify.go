@@ -0,0 +1,243 @@
+package anzsic
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Input is a piece of text describing a business, weighted by how much we
+// trust it (a directory category says more than a homepage title).
+type Input struct {
+	Text   string
+	Weight float64
+}
+
+// Classification is the most specific level the classifier could settle on.
+// Class is empty when only the subdivision or division could be determined.
+type Classification struct {
+	Division    string
+	Subdivision string
+	Class       string
+	Title       string  // Title of the most specific code assigned
+	Confidence  float64 // 0..1
+}
+
+func (c Classification) IsZero() bool {
+	return c.Division == ""
+}
+
+// Code returns the most specific code assigned.
+func (c Classification) Code() string {
+	switch {
+	case c.Class != "":
+		return c.Class
+	case c.Subdivision != "":
+		return c.Subdivision
+	}
+	return c.Division
+}
+
+// Matches reports whether the classification falls under any of the given
+// division, subdivision or class codes.
+func (c Classification) Matches(codes []string) bool {
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+		if code == c.Division || code == c.Subdivision || code == c.Class {
+			return true
+		}
+	}
+	return false
+}
+
+// Titles carry these words at every level, so they say nothing on their own.
+var genericTokens = map[string]bool{
+	"and": true, "or": true, "of": true, "the": true, "for": true, "except": true,
+	"including": true, "other": true, "n": true, "e": true, "c": true, "based": true,
+	"factory": true, "non": true, "product": true, "manufacturing": true,
+	"service": true, "activity": true, "related": true, "support": true,
+	"operation": true, "wholesaling": true, "retailing": true, "equipment": true,
+	"general": true, "line": true, "good": true, "use": true, "own": true,
+	"system": true, "provider": true,
+}
+
+const (
+	keywordPoints = 2.0
+	titlePoints   = 0.5
+	// Share of a parent's score a child inherits
+	parentShare = 0.5
+)
+
+type phrase struct {
+	text   string // normalized, padded with spaces
+	tokens int
+}
+
+type Classifier struct {
+	taxonomy *Taxonomy
+	phrases  [][]phrase // keyword phrases per entry
+	titles   [][]string // significant title tokens per entry
+}
+
+func NewClassifier(t *Taxonomy) *Classifier {
+	if t == nil {
+		t = Default()
+	}
+	c := &Classifier{
+		taxonomy: t,
+		phrases:  make([][]phrase, len(t.entries)),
+		titles:   make([][]string, len(t.entries)),
+	}
+	for i, e := range t.entries {
+		for _, kw := range e.Keywords {
+			tokens := tokenize(kw)
+			if len(tokens) == 0 {
+				continue
+			}
+			c.phrases[i] = append(c.phrases[i], phrase{text: " " + strings.Join(tokens, " ") + " ", tokens: len(tokens)})
+		}
+		seen := make(map[string]bool)
+		for _, tok := range tokenize(e.Title) {
+			if genericTokens[tok] || len(tok) < 3 || seen[tok] {
+				continue
+			}
+			seen[tok] = true
+			c.titles[i] = append(c.titles[i], tok)
+		}
+	}
+	return c
+}
+
+// Classify scores every code against the inputs. Keyword phrases score more
+// than title words, and longer phrases more than single words. A code also
+// inherits part of its parents' scores, so "Manufacturing" from a directory
+// category breaks ties between classes in Division C.
+func (c *Classifier) Classify(inputs []Input) Classification {
+	own := make([]float64, len(c.taxonomy.entries))
+	matched := false
+	for _, in := range inputs {
+		if in.Weight <= 0 {
+			continue
+		}
+		tokens := tokenize(in.Text)
+		if len(tokens) == 0 {
+			continue
+		}
+		text := " " + strings.Join(tokens, " ") + " "
+		present := make(map[string]bool, len(tokens))
+		for _, tok := range tokens {
+			present[tok] = true
+		}
+
+		for i := range c.taxonomy.entries {
+			var points float64
+			for _, p := range c.phrases[i] {
+				if strings.Contains(text, p.text) {
+					points += keywordPoints * (1 + 0.5*float64(p.tokens-1))
+				}
+			}
+			for _, tok := range c.titles[i] {
+				if present[tok] {
+					points += titlePoints
+				}
+			}
+			if points > 0 {
+				own[i] += points * in.Weight
+				matched = true
+			}
+		}
+	}
+	if !matched {
+		return Classification{}
+	}
+
+	total := make([]float64, len(own))
+	for i, e := range c.taxonomy.entries {
+		total[i] = own[i]
+		if e.Level >= LevelSubdivision {
+			total[i] += parentShare * c.ownScore(own, e.Division)
+		}
+		if e.Level == LevelClass {
+			total[i] += parentShare * c.ownScore(own, e.Subdivision())
+		}
+	}
+
+	// Settle on the most specific level with direct evidence
+	for _, level := range []Level{LevelClass, LevelSubdivision, LevelDivision} {
+		var candidates []int
+		for i, e := range c.taxonomy.entries {
+			if e.Level == level && own[i] > 0 {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+		sort.SliceStable(candidates, func(a, b int) bool { return total[candidates[a]] > total[candidates[b]] })
+
+		best := candidates[0]
+		second := 0.0
+		if len(candidates) > 1 {
+			second = total[candidates[1]]
+		}
+		return c.result(best, total[best], second)
+	}
+	return Classification{}
+}
+
+func (c *Classifier) ownScore(own []float64, code string) float64 {
+	if i, ok := c.taxonomy.byCode[code]; ok {
+		return own[i]
+	}
+	return 0
+}
+
+// result builds the classification for entry i. Confidence grows with the
+// strength of the evidence and the margin over the runner-up, and is damped
+// when we couldn't get down to a class.
+func (c *Classifier) result(i int, best, second float64) Classification {
+	e := c.taxonomy.entries[i]
+	strength := 1 - math.Exp(-best/4)
+	margin := (best - second) / best
+	confidence := strength * (0.5 + 0.5*margin)
+
+	out := Classification{Division: e.Division, Title: e.Title}
+	switch e.Level {
+	case LevelClass:
+		out.Subdivision = e.Subdivision()
+		out.Class = e.Code
+	case LevelSubdivision:
+		out.Subdivision = e.Code
+		confidence *= 0.75
+	case LevelDivision:
+		confidence *= 0.5
+	}
+	out.Confidence = math.Round(confidence*100) / 100
+	return out
+}
+
+// tokenize lowercases text, splits it on anything but letters, digits and &,
+// and folds simple plurals so "brewers" matches "brewer".
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '&'
+	})
+	for i, f := range fields {
+		fields[i] = stem(f)
+	}
+	return fields
+}
+
+func stem(tok string) string {
+	switch {
+	case len(tok) > 4 && strings.HasSuffix(tok, "ies"):
+		return tok[:len(tok)-3] + "y"
+	case len(tok) > 3 && strings.HasSuffix(tok, "s") && !strings.HasSuffix(tok, "ss") && !strings.HasSuffix(tok, "us"):
+		return tok[:len(tok)-1]
+	}
+	return tok
+}
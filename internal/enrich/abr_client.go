@@ -168,8 +168,16 @@ func (c *ABRClient) Enrich(ctx context.Context, l *model.Lead) error {
 		return err
 	}
 
+	// A stored lead being refreshed mustn't keep flags the ABR no longer
+	// reports, e.g. a cancelled GST registration
+	before := *l
+	l.IsGSTRegistered, l.GSTEffectiveFrom = false, time.Time{}
+	l.IsNFP, l.IsCurrentEntity = false, false
+
 	decoder := xml.NewDecoder(strings.NewReader(string(body)))
 	foundData := false
+	// What the ABR says, whether or not the source already gave a value
+	asserted := make(map[string]string)
 
 	for {
 		t, err := decoder.Token()
@@ -187,6 +195,14 @@ func (c *ABRClient) Enrich(ctx context.Context, l *model.Lead) error {
 			case "entitydescription":
 				decoder.DecodeElement(&l.EntityType, &se)
 				foundData = true
+			case "entitytypecode":
+				var code string
+				decoder.DecodeElement(&code, &se)
+				l.EntityTypeCode = model.ParseEntityTypeCode(code)
+			case "charitytype", "taxconcessioncharityendorsement", "acncregistration":
+				// Only present for charities and other tax-concession NFPs
+				l.IsNFP = true
+				decoder.Skip()
 			case "entitystatuscode":
 				decoder.DecodeElement(&l.EntityStatus, &se)
 			case "iscurrentindicator":
@@ -196,16 +212,16 @@ func (c *ABRClient) Enrich(ctx context.Context, l *model.Lead) error {
 			case "asicnumber":
 				decoder.DecodeElement(&l.ACN, &se)
 			case "organisationname":
-				if l.MainTradingName == "" {
-					decoder.DecodeElement(&l.MainTradingName, &se)
+				if asserted[model.FieldMainTradingName] == "" {
+					asserted[model.FieldMainTradingName] = decodeString(decoder, &se)
 				}
 			case "contactphonenumber":
-				if l.Phone == "" {
-					decoder.DecodeElement(&l.Phone, &se)
+				if asserted[model.FieldPhone] == "" {
+					asserted[model.FieldPhone] = decodeString(decoder, &se)
 				}
 			case "contactemail":
-				if l.Email == "" {
-					decoder.DecodeElement(&l.Email, &se)
+				if asserted[model.FieldEmail] == "" {
+					asserted[model.FieldEmail] = decodeString(decoder, &se)
 				}
 			case "goodsandservicestax":
 				var gst struct {
@@ -227,12 +243,12 @@ func (c *ABRClient) Enrich(ctx context.Context, l *model.Lead) error {
 					}
 				}
 			case "statecode":
-				if l.State == "" {
-					decoder.DecodeElement(&l.State, &se)
+				if asserted[model.FieldState] == "" {
+					asserted[model.FieldState] = decodeString(decoder, &se)
 				}
 			case "postcode":
-				if l.Postcode == "" {
-					decoder.DecodeElement(&l.Postcode, &se)
+				if asserted[model.FieldPostcode] == "" {
+					asserted[model.FieldPostcode] = decodeString(decoder, &se)
 				}
 			}
 		}
@@ -244,7 +260,22 @@ func (c *ABRClient) Enrich(ctx context.Context, l *model.Lead) error {
 			snippet = snippet[:500]
 		}
 		c.logger.Error("Enrichment missed data", "abn", l.ABN, "resp", snippet)
+		*l = before
 		return fmt.Errorf("no business data found for ABN %s", l.ABN)
 	}
+
+	now := time.Now()
+	for field, value := range asserted {
+		l.Assert(model.AsserterABR, field, value, now)
+		if l.Field(field) == "" {
+			l.SetField(field, strings.TrimSpace(value))
+		}
+	}
 	return nil
 }
+
+func decodeString(decoder *xml.Decoder, se *xml.StartElement) string {
+	var s string
+	decoder.DecodeElement(&s, se)
+	return s
+}
@@ -0,0 +1,33 @@
+package enrich
+
+import (
+	"context"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// CharityLookup finds a lead in the imported ACNC register.
+type CharityLookup interface {
+	GetCharity(ctx context.Context, abn string) (*model.Charity, error)
+}
+
+// CharityEnricher flags leads that are registered charities.
+type CharityEnricher struct {
+	lookup CharityLookup
+}
+
+func NewCharityEnricher(lookup CharityLookup) *CharityEnricher {
+	return &CharityEnricher{lookup: lookup}
+}
+
+func (c *CharityEnricher) Enrich(ctx context.Context, l *model.Lead) error {
+	if l.ABN == "" {
+		return nil
+	}
+	charity, err := c.lookup.GetCharity(ctx, l.ABN)
+	if err != nil {
+		return err
+	}
+	l.Charity = charity
+	return nil
+}
@@ -0,0 +1,38 @@
+package enrich
+
+import (
+	"context"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/geo"
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// GeoEnricher fills location details from the lead's postcode.
+type GeoEnricher struct {
+	data *geo.Dataset
+}
+
+func NewGeoEnricher(data *geo.Dataset) *GeoEnricher {
+	if data == nil {
+		data = geo.Default()
+	}
+	return &GeoEnricher{data: data}
+}
+
+func (g *GeoEnricher) Enrich(ctx context.Context, l *model.Lead) error {
+	postcode := geo.NormalizePostcode(l.Postcode)
+	if postcode == "" {
+		return nil
+	}
+	l.Postcode = postcode
+
+	expected := g.data.StateFor(postcode)
+	if l.State == "" {
+		l.State = expected
+	}
+	l.StateMismatch = expected != "" && !strings.EqualFold(l.State, expected)
+
+	l.Locate(g.data)
+	return nil
+}
@@ -0,0 +1,32 @@
+package enrich
+
+import (
+	"context"
+
+	"github.com/shanehull/sourcerer/internal/anzsic"
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// IndustryClassifier assigns an ANZSIC classification from what we know about
+// the lead. Run it after the website enricher so homepage text is available.
+type IndustryClassifier struct {
+	classifier *anzsic.Classifier
+}
+
+func NewIndustryClassifier(taxonomy *anzsic.Taxonomy) *IndustryClassifier {
+	return &IndustryClassifier{classifier: anzsic.NewClassifier(taxonomy)}
+}
+
+func (c *IndustryClassifier) Enrich(ctx context.Context, l *model.Lead) error {
+	// "General" and the like are common directory categories that carry no
+	// signal; they simply won't match anything.
+	l.Industry = c.classifier.Classify([]anzsic.Input{
+		{Text: l.Category, Weight: 1},
+		{Text: l.SearchKeyword, Weight: 1.5},
+		{Text: l.Name, Weight: 1},
+		{Text: l.MainTradingName, Weight: 1},
+		{Text: l.Website.Title, Weight: 0.75},
+		{Text: l.Website.Description, Weight: 0.75},
+	})
+	return nil
+}
@@ -0,0 +1,33 @@
+package enrich
+
+import (
+	"context"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// TaxLookup finds a lead in the imported ATO tax transparency data.
+type TaxLookup interface {
+	GetLatestTaxRecord(ctx context.Context, abn string) (*model.TaxRecord, error)
+}
+
+// TaxEnricher attaches the lead's latest ATO-reported income, if any.
+type TaxEnricher struct {
+	lookup TaxLookup
+}
+
+func NewTaxEnricher(lookup TaxLookup) *TaxEnricher {
+	return &TaxEnricher{lookup: lookup}
+}
+
+func (t *TaxEnricher) Enrich(ctx context.Context, l *model.Lead) error {
+	if l.ABN == "" {
+		return nil
+	}
+	record, err := t.lookup.GetLatestTaxRecord(ctx, l.ABN)
+	if err != nil {
+		return err
+	}
+	l.Tax = record
+	return nil
+}
@@ -0,0 +1,213 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	app "github.com/lib4u/fake-useragent"
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// maxPageBytes caps how much of a homepage we read
+const maxPageBytes = 5 << 20
+
+// WebsiteEnricher inspects a lead's homepage for digital-maturity signals.
+type WebsiteEnricher struct {
+	logger *slog.Logger
+	ua     *app.UserAgent
+	client *http.Client
+}
+
+func NewWebsiteEnricher(logger *slog.Logger) *WebsiteEnricher {
+	ua, err := app.New()
+	if err != nil {
+		logger.Error("Failed to initialize user agent", "err", err)
+	}
+	return &WebsiteEnricher{
+		logger: logger,
+		ua:     ua,
+		client: &http.Client{
+			Timeout: 20 * time.Second,
+		},
+	}
+}
+
+func (w *WebsiteEnricher) Enrich(ctx context.Context, l *model.Lead) error {
+	if l.BusinessURL == "" {
+		return nil
+	}
+
+	siteURL := l.BusinessURL
+	if !strings.HasPrefix(siteURL, "http://") && !strings.HasPrefix(siteURL, "https://") {
+		siteURL = "https://" + siteURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", siteURL, nil)
+	if err != nil {
+		return err
+	}
+	ua := "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Safari/537.36"
+	if w.ua != nil {
+		ua = w.ua.GetRandom()
+	}
+	req.Header.Set("User-Agent", ua)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("website fetch failed for %s: %w", siteURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("website %s returned status %d", siteURL, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPageBytes))
+	if err != nil {
+		return err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	l.Website = model.WebsiteSignals{
+		CheckedAt:     time.Now(),
+		Platform:      detectPlatform(doc, body),
+		HTTPS:         resp.Request.URL.Scheme == "https",
+		CopyrightYear: copyrightYear(doc),
+		HasStore:      hasStore(doc, body),
+		HasCareers:    hasCareers(doc),
+		PageBytes:     len(body),
+		Title:         strings.TrimSpace(doc.Find("title").First().Text()),
+		Description:   strings.TrimSpace(doc.Find(`meta[name="description"]`).AttrOr("content", "")),
+	}
+	phone, email := contactLinks(doc)
+	for field, value := range map[string]string{model.FieldPhone: phone, model.FieldEmail: email} {
+		l.Assert(model.AsserterWebsite, field, value, l.Website.CheckedAt)
+		if l.Field(field) == "" {
+			l.SetField(field, value)
+		}
+	}
+	w.logger.Debug("Website signals", "name", l.Name, "url", resp.Request.URL.String(), "platform", l.Website.Platform, "copyright", l.Website.CopyrightYear)
+	return nil
+}
+
+// platformMarkers are checked in order; the first hit wins
+var platformMarkers = []struct {
+	platform string
+	markers  []string
+}{
+	{"Shopify", []string{"cdn.shopify.com", "shopify.theme"}},
+	{"Wix", []string{"static.wixstatic.com", "wix.com website builder"}},
+	{"Squarespace", []string{"static1.squarespace.com", "squarespace.com"}},
+	{"Webflow", []string{"webflow.com", "data-wf-site"}},
+	{"WordPress", []string{"wp-content/", "wp-includes/"}},
+	{"Joomla", []string{"/media/jui/", "joomla!"}},
+	{"Drupal", []string{"drupal.settings", "/sites/default/files/"}},
+	{"Magento", []string{"mage/cookies", "magento"}},
+	{"BigCommerce", []string{"cdn11.bigcommerce.com", "bigcommerce"}},
+	{"Weebly", []string{"weebly.com", "editmysite.com"}},
+	{"GoDaddy", []string{"img1.wsimg.com"}},
+	{"HubSpot", []string{"hs-scripts.com", "hubspot"}},
+}
+
+func detectPlatform(doc *goquery.Document, body []byte) string {
+	generator := strings.ToLower(doc.Find(`meta[name="generator"]`).AttrOr("content", ""))
+	lower := strings.ToLower(string(body))
+	for _, p := range platformMarkers {
+		if strings.Contains(generator, strings.ToLower(p.platform)) {
+			return p.platform
+		}
+		for _, m := range p.markers {
+			if strings.Contains(lower, m) {
+				return p.platform
+			}
+		}
+	}
+	if generator != "" {
+		return strings.TrimSpace(strings.SplitN(generator, " ", 2)[0])
+	}
+	return ""
+}
+
+var copyrightRe = regexp.MustCompile(`(?i)(?:©|\(c\)|copyright)\s*(?:\d{4}\s*[-–—]\s*)?((?:19|20)\d{2})`)
+
+// copyrightYear returns the latest copyright year, preferring the footer.
+func copyrightYear(doc *goquery.Document) int {
+	text := doc.Find("footer, #footer, .footer, [class*=footer]").Text()
+	if year := latestYear(text); year > 0 {
+		return year
+	}
+	return latestYear(doc.Find("body").Text())
+}
+
+func latestYear(text string) int {
+	latest := 0
+	for _, m := range copyrightRe.FindAllStringSubmatch(text, -1) {
+		year, _ := strconv.Atoi(m[1])
+		if year > latest && year <= time.Now().Year() {
+			latest = year
+		}
+	}
+	return latest
+}
+
+func hasStore(doc *goquery.Document, body []byte) bool {
+	lower := strings.ToLower(string(body))
+	for _, m := range []string{"woocommerce", "add to cart", "add-to-cart", "cdn.shopify.com", "bigcommerce"} {
+		if strings.Contains(lower, m) {
+			return true
+		}
+	}
+	found := false
+	doc.Find("a[href]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		href := strings.ToLower(s.AttrOr("href", ""))
+		if strings.Contains(href, "/cart") || strings.Contains(href, "/checkout") || strings.HasSuffix(href, "/shop") || strings.Contains(href, "/shop/") {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// contactLinks returns the first tel: and mailto: link targets on the page.
+func contactLinks(doc *goquery.Document) (phone, email string) {
+	doc.Find(`a[href^="tel:"], a[href^="mailto:"]`).Each(func(_ int, s *goquery.Selection) {
+		href := s.AttrOr("href", "")
+		if v, ok := strings.CutPrefix(href, "tel:"); ok && phone == "" {
+			phone = strings.TrimSpace(v)
+		}
+		if v, ok := strings.CutPrefix(href, "mailto:"); ok && email == "" {
+			// Drop any ?subject= and the like
+			email = strings.TrimSpace(strings.SplitN(v, "?", 2)[0])
+		}
+	})
+	return phone, email
+}
+
+func hasCareers(doc *goquery.Document) bool {
+	found := false
+	doc.Find("a[href]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		text := strings.ToLower(s.Text() + " " + s.AttrOr("href", ""))
+		for _, m := range []string{"career", "jobs", "employment", "join our team", "work with us", "vacancies"} {
+			if strings.Contains(text, m) {
+				found = true
+				break
+			}
+		}
+		return !found
+	})
+	return found
+}
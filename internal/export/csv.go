@@ -0,0 +1,61 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+// CSV writes a header row then a row per lead. Values are written the way
+// DuckDB's COPY wrote the export before there were other formats, so
+// existing sheets and scripts read it unchanged.
+type CSV struct{}
+
+func (CSV) Export(w io.Writer, rows *storage.ExportRows) error {
+	cw := csv.NewWriter(w)
+	columns := rows.Columns()
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.Name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for rows.Next() {
+		for i, v := range rows.Values() {
+			record[i] = formatCSV(v)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCSV(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		// Whole numbers keep a decimal place, marking the column as real
+		if x == math.Trunc(x) && math.Abs(x) < 1e15 {
+			return strconv.FormatFloat(x, 'f', 1, 64)
+		}
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case time.Time:
+		// Dates too, as the timestamps they're stored as
+		return x.Format("2006-01-02 15:04:05.999999")
+	}
+	return ""
+}
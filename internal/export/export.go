@@ -0,0 +1,122 @@
+// Package export writes exported leads to files: CSV for the pipeline's
+// usual output, JSON Lines and Parquet for data tooling, and a formatted
+// XLSX workbook for analysts. Profiles reshape leads for CRM imports and
+// address books.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+// Exporter writes exported leads to w, in its format.
+type Exporter interface {
+	Export(w io.Writer, rows *storage.ExportRows) error
+}
+
+// Formats lists the supported formats, which are also their file extensions.
+var Formats = []string{"csv", "jsonl", "parquet", "xlsx"}
+
+// New returns the exporter for format.
+func New(format string) (Exporter, error) {
+	switch format {
+	case "csv":
+		return CSV{}, nil
+	case "jsonl":
+		return JSONLines{}, nil
+	case "parquet":
+		return Parquet{}, nil
+	case "xlsx":
+		return XLSX{}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// Format picks the format for an export to path: the given format if set,
+// else the one path's extension names.
+func Format(format, path string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+		if _, err := New(format); err != nil {
+			return "", err
+		}
+		return format, nil
+	}
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")); ext {
+	case "csv", "jsonl", "parquet", "xlsx":
+		return ext, nil
+	case "ndjson":
+		return "jsonl", nil
+	}
+	return "", fmt.Errorf("can't tell the format of %s from its extension; set one of %s", path, strings.Join(Formats, ", "))
+}
+
+// Leads exports the given columns of the leads q selects to path, in format.
+// Nil columns is every column.
+func Leads(ctx context.Context, repo storage.Repository, path, format string, q storage.LeadQuery, columns []storage.ExportColumn) error {
+	exporter, err := New(format)
+	if err != nil {
+		return err
+	}
+	rows, err := repo.ExportLeads(ctx, q, columns)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return write(path, exporter, rows)
+}
+
+// Delta exports to path, in format, only the leads that changed against the
+// named watermark, with a change_type column first. Once the file is
+// written the watermark moves on, so the next delta starts from this one. It
+// returns how many leads each kind of change carried.
+func Delta(ctx context.Context, repo storage.Repository, path, format string, q storage.LeadQuery, columns []storage.ExportColumn, watermark string) (map[model.ExportChange]int, error) {
+	exporter, err := New(format)
+	if err != nil {
+		return nil, err
+	}
+	// Changes saved while the export runs are left for the next one
+	start := time.Now()
+	rows, err := repo.ExportDelta(ctx, q, columns, watermark)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if err := write(path, exporter, rows); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	changes := rows.Changes()
+	if err := repo.AdvanceExportWatermark(ctx, watermark, start, changes); err != nil {
+		return nil, fmt.Errorf("exported, but the watermark didn't advance: %w", err)
+	}
+	counts := make(map[model.ExportChange]int)
+	for _, c := range changes {
+		counts[c]++
+	}
+	return counts, nil
+}
+
+func write(path string, exporter Exporter, rows *storage.ExportRows) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := exporter.Export(f, rows); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,47 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+// JSONLines writes a JSON object per lead, one per line, with keys in column
+// order. Missing values are null, dates are YYYY-MM-DD and times RFC 3339.
+type JSONLines struct{}
+
+func (JSONLines) Export(w io.Writer, rows *storage.ExportRows) error {
+	bw := bufio.NewWriter(w)
+	columns := rows.Columns()
+	keys := make([][]byte, len(columns))
+	for i, c := range columns {
+		keys[i], _ = json.Marshal(c.Name)
+	}
+	for rows.Next() {
+		bw.WriteByte('{')
+		for i, v := range rows.Values() {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.Write(keys[i])
+			bw.WriteByte(':')
+			if t, ok := v.(time.Time); ok {
+				if columns[i].Kind == storage.KindDate {
+					v = t.Format("2006-01-02")
+				} else {
+					v = t.Format(time.RFC3339Nano)
+				}
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			bw.Write(data)
+		}
+		bw.WriteString("}\n")
+	}
+	return bw.Flush()
+}
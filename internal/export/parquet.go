@@ -0,0 +1,121 @@
+package export
+
+import (
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+// Parquet writes a Snappy-compressed Parquet file with a typed, optional
+// column per export column: dates as DATE, times as microsecond TIMESTAMP.
+type Parquet struct{}
+
+// parquetBatch is how many leads are buffered per write.
+const parquetBatch = 1024
+
+func (Parquet) Export(w io.Writer, rows *storage.ExportRows) error {
+	columns := rows.Columns()
+	group := orderedGroup{Group: parquet.Group{}}
+	for _, c := range columns {
+		node := parquet.Optional(parquetNode(c.Kind))
+		group.Group[c.Name] = node
+		group.fields = append(group.fields, groupField{Node: node, name: c.Name})
+	}
+	schema := parquet.NewSchema("lead", group)
+
+	// Leaves are numbered in schema order, which is column order only as
+	// long as the group keeps it, so look each up
+	index := make([]int, len(columns))
+	for i, c := range columns {
+		leaf, _ := schema.Lookup(c.Name)
+		index[i] = leaf.ColumnIndex
+	}
+
+	pw := parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy))
+	batch := make([]parquet.Row, 0, parquetBatch)
+	for rows.Next() {
+		row := make(parquet.Row, len(columns))
+		for i, v := range rows.Values() {
+			row[index[i]] = parquetValue(v, columns[i].Kind, index[i])
+		}
+		batch = append(batch, row)
+		if len(batch) == parquetBatch {
+			if _, err := pw.WriteRows(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if _, err := pw.WriteRows(batch); err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+func parquetNode(kind storage.ColumnKind) parquet.Node {
+	switch kind {
+	case storage.KindInt:
+		return parquet.Int(64)
+	case storage.KindFloat:
+		return parquet.Leaf(parquet.DoubleType)
+	case storage.KindBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case storage.KindDate:
+		return parquet.Date()
+	case storage.KindTime:
+		return parquet.Timestamp(parquet.Microsecond)
+	}
+	return parquet.String()
+}
+
+func parquetValue(v interface{}, kind storage.ColumnKind, column int) parquet.Value {
+	var value parquet.Value
+	switch x := v.(type) {
+	case nil:
+		return parquet.NullValue().Level(0, 0, column)
+	case string:
+		value = parquet.ByteArrayValue([]byte(x))
+	case int64:
+		value = parquet.Int64Value(x)
+	case float64:
+		value = parquet.DoubleValue(x)
+	case bool:
+		value = parquet.BooleanValue(x)
+	case time.Time:
+		if kind == storage.KindDate {
+			value = parquet.Int32Value(int32(x.Unix() / 86400))
+		} else {
+			value = parquet.Int64Value(x.UnixMicro())
+		}
+	}
+	return value.Level(0, 1, column)
+}
+
+// orderedGroup is a parquet.Group that keeps its fields in the order given,
+// rather than sorted by name, so the file's columns read in export order.
+type orderedGroup struct {
+	parquet.Group
+	fields []groupField
+}
+
+func (g orderedGroup) Fields() []parquet.Field {
+	fields := make([]parquet.Field, len(g.fields))
+	for i := range g.fields {
+		fields[i] = &g.fields[i]
+	}
+	return fields
+}
+
+type groupField struct {
+	parquet.Node
+	name string
+}
+
+func (f *groupField) Name() string { return f.name }
+
+func (f *groupField) Value(base reflect.Value) reflect.Value {
+	return base.MapIndex(reflect.ValueOf(f.name))
+}
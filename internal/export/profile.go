@@ -0,0 +1,219 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+// Profile writes leads in the shape something downstream imports, like a
+// CRM's import template, so nobody has to reshape the export by hand.
+type Profile interface {
+	// Ext is the extension of the files the profile writes.
+	Ext() string
+	Write(w io.Writer, leads []model.Lead) error
+}
+
+// Profiles are the available profiles by name.
+var Profiles = map[string]Profile{
+	"hubspot":    hubSpot,
+	"salesforce": salesforce,
+	"pipedrive":  pipedrive,
+	"vcard":      VCard{},
+}
+
+// ProfileNames returns the profile names, sorted.
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileByName returns the named profile.
+func ProfileByName(name string) (Profile, error) {
+	p, ok := Profiles[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (want one of %s)", name, strings.Join(ProfileNames(), ", "))
+	}
+	return p, nil
+}
+
+// LeadsProfile writes the leads q selects to path through profile p.
+func LeadsProfile(ctx context.Context, repo storage.Repository, path string, p Profile, q storage.LeadQuery) error {
+	leads, err := repo.ExportableLeads(ctx, q)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := p.Write(f, leads); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// CRMProfile maps leads onto a CRM's company import CSV. Headers are the
+// ones the CRM's importer matches on its own; the custom properties need
+// creating in the CRM once, under the same names.
+type CRMProfile struct {
+	Columns []CRMColumn
+}
+
+// CRMColumn is one column of a CRM import.
+type CRMColumn struct {
+	Header string
+	Value  func(l model.Lead) string
+}
+
+func (CRMProfile) Ext() string { return "csv" }
+
+func (p CRMProfile) Write(w io.Writer, leads []model.Lead) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(p.Columns))
+	for i, c := range p.Columns {
+		record[i] = c.Header
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for _, l := range leads {
+		for i, c := range p.Columns {
+			record[i] = c.Value(l)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var hubSpot = CRMProfile{Columns: []CRMColumn{
+	{"Company name", companyName},
+	{"Company Domain Name", domain},
+	{"Website URL", website},
+	{"Phone Number", phone},
+	{"City", city},
+	{"State/Region", state},
+	{"Postal Code", postcode},
+	{"Country/Region", country},
+	{"Year Founded", yearFounded},
+	{"Description", description},
+	{"ABN", abn},
+	{"Business Age", age},
+	{"Lead Source", leadSource},
+	{"ANZSIC Industry", industry},
+}}
+
+// Salesforce's Data Import Wizard and Data Loader both match API names.
+var salesforce = CRMProfile{Columns: []CRMColumn{
+	{"Name", companyName},
+	{"Website", website},
+	{"Phone", phone},
+	{"BillingCity", city},
+	{"BillingState", state},
+	{"BillingPostalCode", postcode},
+	{"BillingCountry", country},
+	{"Description", description},
+	{"AccountSource", func(model.Lead) string { return "Sourcerer" }},
+	{"ABN__c", abn},
+	{"Business_Age__c", age},
+	{"Lead_Source__c", leadSource},
+	{"ANZSIC_Industry__c", industry},
+}}
+
+var pipedrive = CRMProfile{Columns: []CRMColumn{
+	{"Organization - Name", companyName},
+	{"Organization - Address", address},
+	{"Organization - Website", website},
+	{"Organization - Phone", phone},
+	{"Organization - ABN", abn},
+	{"Organization - Business age", age},
+	{"Organization - Lead source", leadSource},
+	{"Organization - ANZSIC industry", industry},
+}}
+
+// companyName prefers the registered name, which is what the ABN is for.
+func companyName(l model.Lead) string {
+	if l.Name != "" {
+		return l.Name
+	}
+	return l.MainTradingName
+}
+
+func website(l model.Lead) string { return l.BusinessURL }
+
+// domain is the website's host without a leading www, the key HubSpot
+// deduplicates companies on.
+func domain(l model.Lead) string { return model.WebsiteDomain(l.BusinessURL) }
+
+func phone(l model.Lead) string { return l.Phone }
+
+// city is the lead's local government area, the nearest thing to a town
+// we hold.
+func city(l model.Lead) string { return l.LGA }
+
+func state(l model.Lead) string { return l.State }
+
+func postcode(l model.Lead) string { return l.Postcode }
+
+func country(model.Lead) string { return "Australia" }
+
+// address is a single-line address for CRMs that geocode one field.
+func address(l model.Lead) string {
+	var parts []string
+	if l.LGA != "" {
+		parts = append(parts, l.LGA)
+	}
+	if area := strings.TrimSpace(l.State + " " + l.Postcode); area != "" {
+		parts = append(parts, area)
+	}
+	return strings.Join(append(parts, "Australia"), ", ")
+}
+
+func yearFounded(l model.Lead) string {
+	if l.RegistrationDate.IsZero() {
+		return ""
+	}
+	return strconv.Itoa(l.RegistrationDate.Year())
+}
+
+func description(l model.Lead) string {
+	if l.MainTradingName != "" && !strings.EqualFold(l.MainTradingName, l.Name) {
+		return "Trading as " + l.MainTradingName
+	}
+	return ""
+}
+
+func abn(l model.Lead) string { return l.ABN }
+
+func age(l model.Lead) string {
+	if l.RegistrationDate.IsZero() {
+		return ""
+	}
+	return strconv.Itoa(l.AgeYears())
+}
+
+// leadSource lists the directories that listed the lead.
+func leadSource(l model.Lead) string { return strings.Join(l.Sources, "; ") }
+
+func industry(l model.Lead) string {
+	if l.Industry.Title == "" {
+		return l.Category
+	}
+	return l.Industry.Title
+}
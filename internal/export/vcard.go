@@ -0,0 +1,97 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// VCard writes a vCard 3.0 per lead, all in one .vcf file, for address
+// books and phones. Each card is an organisation, with the ABN, age and
+// sources in its note.
+type VCard struct{}
+
+func (VCard) Ext() string { return "vcf" }
+
+func (VCard) Write(w io.Writer, leads []model.Lead) error {
+	bw := bufio.NewWriter(w)
+	now := time.Now().UTC().Format("20060102T150405Z")
+	for _, l := range leads {
+		name := companyName(l)
+		lines := []string{
+			"BEGIN:VCARD",
+			"VERSION:3.0",
+			// Apple and Google show an organisation rather than a person
+			// for this
+			"X-ABSHOWAS:COMPANY",
+			"FN:" + vcardEscape(name),
+			"N:;;;;",
+			"ORG:" + vcardEscape(name),
+		}
+		if l.Phone != "" {
+			lines = append(lines, "TEL;TYPE=WORK,VOICE:"+vcardEscape(l.Phone))
+		}
+		if l.Email != "" {
+			lines = append(lines, "EMAIL;TYPE=INTERNET,WORK:"+vcardEscape(l.Email))
+		}
+		if l.BusinessURL != "" {
+			lines = append(lines, "URL:"+vcardEscape(l.BusinessURL))
+		}
+		if l.State != "" || l.Postcode != "" || l.LGA != "" {
+			// PO box; extended; street; locality; region; postcode; country
+			lines = append(lines, "ADR;TYPE=WORK:;;;"+vcardEscape(l.LGA)+";"+vcardEscape(l.State)+";"+vcardEscape(l.Postcode)+";Australia")
+		}
+		note := []string{"ABN " + l.ABN}
+		if a := age(l); a != "" {
+			note = append(note, "Age "+a+" years")
+		}
+		if s := leadSource(l); s != "" {
+			note = append(note, "Sources: "+s)
+		}
+		lines = append(lines,
+			"NOTE:"+vcardEscape(strings.Join(note, "\n")),
+			"X-ABN:"+vcardEscape(l.ABN),
+			"UID:urn:abn:"+l.ABN,
+			"REV:"+now,
+			"END:VCARD",
+		)
+		for _, line := range lines {
+			if _, err := bw.WriteString(vcardFold(line) + "\r\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// vcardEscape escapes a property value as RFC 2426 asks.
+func vcardEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// vcardFold folds a line longer than 75 octets onto continuation lines,
+// without splitting a UTF-8 sequence.
+func vcardFold(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+	var b strings.Builder
+	width := limit
+	for len(line) > width {
+		cut := width
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines lose an octet to the leading space
+		width = limit - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package export
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/shanehull/sourcerer/internal/storage"
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSX writes an Excel workbook with a sheet per state, or a single Leads
+// sheet when the export has no state column. Each sheet has a bold, frozen
+// and filterable header row, dates and times formatted as such, and URLs as
+// links.
+type XLSX struct{}
+
+const (
+	noStateSheet = "No state"
+	leadsSheet   = "Leads"
+	maxColWidth  = 50
+)
+
+func (XLSX) Export(w io.Writer, rows *storage.ExportRows) error {
+	columns := rows.Columns()
+	stateCol := slices.IndexFunc(columns, func(c storage.ExportColumn) bool { return c.Name == "state" })
+
+	// Sheets are written whole, so buffer the leads by sheet
+	bySheet := make(map[string][][]interface{})
+	for rows.Next() {
+		sheet := leadsSheet
+		if stateCol >= 0 {
+			sheet = noStateSheet
+			if s, _ := rows.Values()[stateCol].(string); strings.TrimSpace(s) != "" {
+				// Excel sheet names ignore case
+				sheet = sheetName(strings.ToUpper(s))
+			}
+		}
+		bySheet[sheet] = append(bySheet[sheet], slices.Clone(rows.Values()))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sheets := make([]string, 0, len(bySheet))
+	for s := range bySheet {
+		sheets = append(sheets, s)
+	}
+	slices.SortFunc(sheets, func(a, b string) int {
+		// States alphabetically, leads without one last
+		if (a == noStateSheet) != (b == noStateSheet) {
+			if a == noStateSheet {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a, b)
+	})
+	if len(sheets) == 0 {
+		sheets = []string{leadsSheet}
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	styles, err := newXLSXStyles(f)
+	if err != nil {
+		return err
+	}
+	for i, sheet := range sheets {
+		if i == 0 {
+			err = f.SetSheetName(f.GetSheetName(0), sheet)
+		} else {
+			_, err = f.NewSheet(sheet)
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeSheet(f, sheet, columns, bySheet[sheet], styles); err != nil {
+			return err
+		}
+	}
+	f.SetActiveSheet(0)
+	return f.Write(w)
+}
+
+type xlsxStyles struct {
+	header, date, time, link int
+}
+
+func newXLSXStyles(f *excelize.File) (xlsxStyles, error) {
+	var s xlsxStyles
+	var err error
+	dateFmt, timeFmt := "yyyy-mm-dd", "yyyy-mm-dd hh:mm:ss"
+	if s.header, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err != nil {
+		return s, err
+	}
+	if s.date, err = f.NewStyle(&excelize.Style{CustomNumFmt: &dateFmt}); err != nil {
+		return s, err
+	}
+	if s.time, err = f.NewStyle(&excelize.Style{CustomNumFmt: &timeFmt}); err != nil {
+		return s, err
+	}
+	s.link, err = f.NewStyle(&excelize.Style{Font: &excelize.Font{Color: "0563C1", Underline: "single"}})
+	return s, err
+}
+
+func writeSheet(f *excelize.File, sheet string, columns []storage.ExportColumn, leads [][]interface{}, styles xlsxStyles) error {
+	header := make([]interface{}, len(columns))
+	widths := make([]int, len(columns))
+	for i, c := range columns {
+		header[i] = c.Name
+		widths[i] = len(c.Name) + 4 // room for the filter button
+	}
+	if err := f.SetSheetRow(sheet, "A1", &header); err != nil {
+		return err
+	}
+	last, _ := excelize.CoordinatesToCellName(len(columns), 1)
+	if err := f.SetCellStyle(sheet, "A1", last, styles.header); err != nil {
+		return err
+	}
+
+	for r, values := range leads {
+		row := r + 2
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			return err
+		}
+		for i, v := range values {
+			if v == nil {
+				continue
+			}
+			cell, _ := excelize.CoordinatesToCellName(i+1, row)
+			switch columns[i].Kind {
+			case storage.KindDate:
+				widths[i] = max(widths[i], 12)
+				if err := f.SetCellStyle(sheet, cell, cell, styles.date); err != nil {
+					return err
+				}
+			case storage.KindTime:
+				widths[i] = max(widths[i], 20)
+				if err := f.SetCellStyle(sheet, cell, cell, styles.time); err != nil {
+					return err
+				}
+			case storage.KindURL:
+				url, _ := v.(string)
+				if url == "" {
+					continue
+				}
+				if !strings.Contains(url, "://") {
+					url = "http://" + url
+				}
+				if err := f.SetCellHyperLink(sheet, cell, url, "External"); err != nil {
+					return err
+				}
+				if err := f.SetCellStyle(sheet, cell, cell, styles.link); err != nil {
+					return err
+				}
+			}
+			if s, ok := v.(string); ok {
+				widths[i] = max(widths[i], utf8.RuneCountInString(s)+2)
+			}
+		}
+	}
+
+	for i, width := range widths {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err := f.SetColWidth(sheet, col, col, float64(min(width, maxColWidth))); err != nil {
+			return err
+		}
+	}
+	if err := f.AutoFilter(sheet, "A1:"+last, nil); err != nil {
+		return err
+	}
+	return f.SetPanes(sheet, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	})
+}
+
+// sheetName makes s a valid sheet name: at most 31 characters, none of
+// which Excel reserves.
+func sheetName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(s))
+	if utf8.RuneCountInString(s) > 31 {
+		s = string([]rune(s)[:31])
+	}
+	return s
+}
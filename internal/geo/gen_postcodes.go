@@ -0,0 +1,78 @@
+//go:build ignore
+
+// gen_postcodes.go regenerates postcodes.csv from the public
+// australian_postcodes.csv, a compilation of every Australian locality with
+// its postcode, LGA, SA4 and coordinates. Run it with go generate from this
+// directory; -src reads a downloaded copy instead.
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/geo"
+)
+
+const sourceURL = "https://raw.githubusercontent.com/matthewproctor/australianpostcodes/master/australian_postcodes.csv"
+
+func main() {
+	src := flag.String("src", sourceURL, "Dataset to convert: a URL or a file path")
+	out := flag.String("o", "postcodes.csv", "File to write")
+	flag.Parse()
+
+	r, err := open(*src)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+	data, err := geo.Load(r)
+	if err != nil {
+		log.Fatalf("load %s: %v", *src, err)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"postcode", "locality", "state", "lga", "sa4", "latitude", "longitude"})
+	localities := data.Localities()
+	postcodes := make(map[string]bool)
+	for _, loc := range localities {
+		postcodes[loc.Postcode] = true
+		w.Write([]string{
+			loc.Postcode, loc.Name, loc.State, loc.LGA, loc.Region,
+			strconv.FormatFloat(loc.Latitude, 'f', 4, 64), strconv.FormatFloat(loc.Longitude, 'f', 4, 64),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Wrote %d localities in %d postcodes to %s", len(localities), len(postcodes), *out)
+}
+
+func open(src string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+		return os.Open(src)
+	}
+	resp, err := http.Get(src)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetch %s: %s", src, resp.Status)
+	}
+	return resp.Body, nil
+}
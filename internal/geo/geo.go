@@ -0,0 +1,284 @@
+package geo
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// postcodes.csv is generated from the public australian_postcodes.csv by
+// gen_postcodes.go, keeping only the columns Load reads. A different dataset
+// can be loaded at runtime with LoadFile; its column names are mapped below.
+//
+//go:generate go run gen_postcodes.go
+//go:embed postcodes.csv
+var postcodesCSV []byte
+
+type Locality struct {
+	Postcode  string
+	Name      string
+	State     string
+	LGA       string
+	Region    string // ABS SA4 name
+	Latitude  float64
+	Longitude float64
+}
+
+// Postcode is the aggregate of every locality sharing a postcode.
+type Postcode struct {
+	Code       string
+	State      string
+	LGA        string // LGA of the first listed locality
+	Region     string // SA4 of the first listed locality
+	Latitude   float64
+	Longitude  float64
+	Localities []Locality
+}
+
+type Dataset struct {
+	postcodes map[string]*Postcode
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultDataset *Dataset
+)
+
+// Default returns the embedded dataset.
+func Default() *Dataset {
+	defaultOnce.Do(func() {
+		d, err := Load(bytes.NewReader(postcodesCSV))
+		if err != nil {
+			panic(fmt.Sprintf("geo: embedded postcode data is invalid: %v", err))
+		}
+		defaultDataset = d
+	})
+	return defaultDataset
+}
+
+func LoadFile(path string) (*Dataset, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open postcode data: %w", err)
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Column aliases so both our compact format and the wider public datasets
+// load, most preferred first. The public dataset's sa4 column is a code, so
+// its names win.
+var columnAliases = map[string][]string{
+	"postcode":  {"postcode"},
+	"locality":  {"locality", "suburb"},
+	"state":     {"state"},
+	"lga":       {"lga", "lga_name_2022", "lga_name", "lgaregion"},
+	"sa4":       {"sa4name_2021", "sa4_name_2021", "sa4name", "sa4_name", "sa4"},
+	"latitude":  {"latitude", "lat", "lat_precise"},
+	"longitude": {"longitude", "long", "lon", "long_precise"},
+}
+
+func Load(r io.Reader) (*Dataset, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	cols := make(map[string]int)
+	for key, aliases := range columnAliases {
+		for _, alias := range aliases {
+			if i, ok := index[alias]; ok {
+				cols[key] = i
+				break
+			}
+		}
+	}
+	for _, required := range []string{"postcode", "state", "latitude", "longitude"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("postcode data missing %q column", required)
+		}
+	}
+
+	d := &Dataset{postcodes: make(map[string]*Postcode)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		get := func(key string) string {
+			if idx, ok := cols[key]; ok && idx < len(record) {
+				return strings.TrimSpace(record[idx])
+			}
+			return ""
+		}
+
+		code := NormalizePostcode(get("postcode"))
+		if code == "" {
+			continue
+		}
+		lat, errLat := strconv.ParseFloat(get("latitude"), 64)
+		long, errLong := strconv.ParseFloat(get("longitude"), 64)
+		if errLat != nil || errLong != nil || (lat == 0 && long == 0) {
+			continue
+		}
+
+		d.add(Locality{
+			Postcode:  code,
+			Name:      get("locality"),
+			State:     strings.ToUpper(get("state")),
+			LGA:       get("lga"),
+			Region:    get("sa4"),
+			Latitude:  lat,
+			Longitude: long,
+		})
+	}
+
+	// Centroid is the mean of the locality points
+	for _, pc := range d.postcodes {
+		var lat, long float64
+		for _, loc := range pc.Localities {
+			lat += loc.Latitude
+			long += loc.Longitude
+		}
+		pc.Latitude = lat / float64(len(pc.Localities))
+		pc.Longitude = long / float64(len(pc.Localities))
+	}
+	return d, nil
+}
+
+func (d *Dataset) add(loc Locality) {
+	pc, ok := d.postcodes[loc.Postcode]
+	if !ok {
+		pc = &Postcode{Code: loc.Postcode, State: loc.State, LGA: loc.LGA, Region: loc.Region}
+		d.postcodes[loc.Postcode] = pc
+	}
+	pc.Localities = append(pc.Localities, loc)
+}
+
+// Localities returns every locality in the dataset by postcode, each
+// postcode's in the order they were loaded.
+func (d *Dataset) Localities() []Locality {
+	var out []Locality
+	for _, pc := range d.postcodes {
+		out = append(out, pc.Localities...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Postcode < out[j].Postcode })
+	return out
+}
+
+// Lookup returns the aggregated postcode, if it is in the dataset.
+func (d *Dataset) Lookup(postcode string) (Postcode, bool) {
+	pc, ok := d.postcodes[NormalizePostcode(postcode)]
+	if !ok {
+		return Postcode{}, false
+	}
+	return *pc, true
+}
+
+// StateFor returns the state for a postcode, preferring the dataset and falling
+// back to Australia Post's allocation ranges.
+func (d *Dataset) StateFor(postcode string) string {
+	if pc, ok := d.Lookup(postcode); ok && pc.State != "" {
+		return pc.State
+	}
+	return StateForPostcode(postcode)
+}
+
+// NormalizePostcode returns the 4 digit form of a postcode ("800" -> "0800"),
+// or "" if it isn't one.
+func NormalizePostcode(postcode string) string {
+	postcode = strings.TrimSpace(postcode)
+	if postcode == "" || len(postcode) > 4 {
+		return ""
+	}
+	if _, err := strconv.Atoi(postcode); err != nil {
+		return ""
+	}
+	return strings.Repeat("0", 4-len(postcode)) + postcode
+}
+
+// StateForPostcode maps a postcode onto its state using Australia Post's
+// allocation ranges. Border anomalies are left to the dataset.
+func StateForPostcode(postcode string) string {
+	code := NormalizePostcode(postcode)
+	if code == "" {
+		return ""
+	}
+	n, _ := strconv.Atoi(code)
+	switch {
+	case n >= 200 && n <= 299, n >= 2600 && n <= 2618, n >= 2900 && n <= 2920:
+		return "ACT"
+	case n >= 800 && n <= 999:
+		return "NT"
+	case n >= 1000 && n <= 2999:
+		return "NSW"
+	case n >= 3000 && n <= 3999, n >= 8000 && n <= 8999:
+		return "VIC"
+	case n >= 4000 && n <= 4999, n >= 9000 && n <= 9999:
+		return "QLD"
+	case n >= 5000 && n <= 5999:
+		return "SA"
+	case n >= 6000 && n <= 6999:
+		return "WA"
+	case n >= 7000 && n <= 7999:
+		return "TAS"
+	}
+	return ""
+}
+
+const earthRadiusKm = 6371.0
+
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// DistanceKm is the great-circle distance between two points.
+func DistanceKm(a, b Point) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := (b.Latitude - a.Latitude) * math.Pi / 180
+	dLong := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLong/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// ParsePoint accepts either a postcode ("3175"), resolved to its centroid, or
+// a "lat,long" pair ("-37.98,145.21").
+func (d *Dataset) ParsePoint(raw string) (Point, error) {
+	raw = strings.TrimSpace(raw)
+	if latRaw, longRaw, found := strings.Cut(raw, ","); found {
+		lat, errLat := strconv.ParseFloat(strings.TrimSpace(latRaw), 64)
+		long, errLong := strconv.ParseFloat(strings.TrimSpace(longRaw), 64)
+		if errLat != nil || errLong != nil || lat < -90 || lat > 90 || long < -180 || long > 180 {
+			return Point{}, fmt.Errorf("invalid coordinates %q", raw)
+		}
+		return Point{Latitude: lat, Longitude: long}, nil
+	}
+
+	pc, ok := d.Lookup(raw)
+	if !ok {
+		return Point{}, fmt.Errorf("unknown postcode %q", raw)
+	}
+	return Point{Latitude: pc.Latitude, Longitude: pc.Longitude}, nil
+}
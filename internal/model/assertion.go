@@ -0,0 +1,120 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Fields that sources and enrichers can disagree on. Each is recorded per
+// asserter and resolved to the canonical value by precedence rules.
+const (
+	FieldName            = "name"
+	FieldCategory        = "category"
+	FieldState           = "state"
+	FieldPostcode        = "postcode"
+	FieldPhone           = "phone"
+	FieldEmail           = "email"
+	FieldBusinessURL     = "business_url"
+	FieldMainTradingName = "main_trading_name"
+)
+
+var AssertedFields = []string{
+	FieldName, FieldCategory, FieldState, FieldPostcode,
+	FieldPhone, FieldEmail, FieldBusinessURL, FieldMainTradingName,
+}
+
+// Asserters other than the sources, which assert under their own names.
+const (
+	AsserterABR     = "ABR"
+	AsserterWebsite = "Website"
+	// Values stored before assertions were recorded
+	AsserterStored = "stored"
+)
+
+// FieldValue is a value one source or enricher asserted for a field, and
+// when it first asserted it.
+type FieldValue struct {
+	Field      string
+	Value      string
+	Asserter   string
+	AssertedAt time.Time
+}
+
+func IsAssertedField(field string) bool {
+	for _, f := range AssertedFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns the lead's current value of an asserted field.
+func (l *Lead) Field(field string) string {
+	switch field {
+	case FieldName:
+		return l.Name
+	case FieldCategory:
+		return l.Category
+	case FieldState:
+		return l.State
+	case FieldPostcode:
+		return l.Postcode
+	case FieldPhone:
+		return l.Phone
+	case FieldEmail:
+		return l.Email
+	case FieldBusinessURL:
+		return l.BusinessURL
+	case FieldMainTradingName:
+		return l.MainTradingName
+	}
+	return ""
+}
+
+func (l *Lead) SetField(field, value string) {
+	switch field {
+	case FieldName:
+		l.Name = value
+	case FieldCategory:
+		l.Category = value
+	case FieldState:
+		l.State = value
+	case FieldPostcode:
+		l.Postcode = value
+	case FieldPhone:
+		l.Phone = value
+	case FieldEmail:
+		l.Email = value
+	case FieldBusinessURL:
+		l.BusinessURL = value
+	case FieldMainTradingName:
+		l.MainTradingName = value
+	}
+}
+
+// Assert records that asserter gave value for field. Empty values assert
+// nothing, and a later assertion by the same asserter replaces an earlier one.
+func (l *Lead) Assert(asserter, field, value string, at time.Time) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	for i, a := range l.Assertions {
+		if a.Asserter == asserter && a.Field == field {
+			if a.Value != value {
+				l.Assertions[i] = FieldValue{Field: field, Value: value, Asserter: asserter, AssertedAt: at}
+			}
+			return
+		}
+	}
+	l.Assertions = append(l.Assertions, FieldValue{Field: field, Value: value, Asserter: asserter, AssertedAt: at})
+}
+
+// AssertFields records the lead's current value of every asserted field as
+// asserter's, e.g. for a lead straight from a scraper.
+func (l *Lead) AssertFields(asserter string, at time.Time) {
+	for _, f := range AssertedFields {
+		l.Assert(asserter, f, l.Field(f), at)
+	}
+}
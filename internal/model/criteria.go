@@ -0,0 +1,54 @@
+package model
+
+import "strings"
+
+// Criterion is one of the pipeline's selection tests.
+type Criterion string
+
+const (
+	CriterionAge      Criterion = "age"      // Registered at least -age years
+	CriterionCurrent  Criterion = "current"  // Current in the ABR
+	CriterionGST      Criterion = "gst"      // Registered for GST, with an effective date
+	CriterionLocation Criterion = "location" // In the states, postcodes, areas and radius
+	CriterionPrivate  Criterion = "private"  // Private company or partnership, not a charity or NFP
+	CriterionIncome   Criterion = "income"   // Within the tax transparency income bounds
+	CriterionWebsite  Criterion = "website"  // Website signals pass the filter
+	CriterionIndustry Criterion = "industry" // In one of the industries
+)
+
+// Criteria lists the criteria in the order the pipeline tests them.
+var Criteria = []Criterion{
+	CriterionAge, CriterionCurrent, CriterionGST, CriterionLocation,
+	CriterionPrivate, CriterionIncome, CriterionWebsite, CriterionIndustry,
+}
+
+// CriteriaResults is a lead's pass or fail on each criterion the pipeline
+// tested. Criteria it didn't get to, like the website check on a lead that
+// already failed, are missing.
+type CriteriaResults map[Criterion]bool
+
+// Passed reports whether the lead was tested and failed nothing.
+func (c CriteriaResults) Passed() bool {
+	return len(c) > 0 && len(c.Failed()) == 0
+}
+
+// Failed returns the failed criteria in test order.
+func (c CriteriaResults) Failed() []Criterion {
+	var failed []Criterion
+	for _, crit := range Criteria {
+		if pass, ok := c[crit]; ok && !pass {
+			failed = append(failed, crit)
+		}
+	}
+	return failed
+}
+
+// RejectedBy is the failed criteria as a comma-separated list, e.g. age,gst.
+func (c CriteriaResults) RejectedBy() string {
+	failed := c.Failed()
+	names := make([]string, len(failed))
+	for i, crit := range failed {
+		names[i] = string(crit)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DealStatus is where the team is with a lead.
+type DealStatus string
+
+const (
+	DealNew         DealStatus = "new"
+	DealResearching DealStatus = "researching"
+	DealContacted   DealStatus = "contacted"
+	DealMeeting     DealStatus = "meeting"
+	DealPassed      DealStatus = "passed"
+	DealDoNotPursue DealStatus = "do-not-pursue"
+)
+
+// DealStatuses lists the statuses in pipeline order.
+var DealStatuses = []DealStatus{DealNew, DealResearching, DealContacted, DealMeeting, DealPassed, DealDoNotPursue}
+
+func ParseDealStatus(raw string) (DealStatus, error) {
+	s := DealStatus(strings.ToLower(strings.TrimSpace(raw)))
+	for _, known := range DealStatuses {
+		if s == known {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("unknown deal status %q (want new, researching, contacted, meeting, passed or do-not-pursue)", raw)
+}
+
+// ParseDealStatuses parses a comma-separated list of statuses.
+func ParseDealStatuses(raw string) ([]DealStatus, error) {
+	var statuses []DealStatus
+	for _, part := range strings.Split(raw, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		s, err := ParseDealStatus(part)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// Closed reports whether the team is done with the lead, one way or the
+// other.
+func (s DealStatus) Closed() bool {
+	return s == DealPassed || s == DealDoNotPursue
+}
+
+// Outcome is the outcome moving a lead to this status records, for ranking.
+// New and researching say nothing yet.
+func (s DealStatus) Outcome() (Outcome, bool) {
+	switch s {
+	case DealContacted:
+		return OutcomeContacted, true
+	case DealMeeting:
+		return OutcomeMeeting, true
+	case DealPassed:
+		return OutcomePassed, true
+	case DealDoNotPursue:
+		return OutcomeRejected, true
+	}
+	return "", false
+}
+
+// Deal is the team's hand-entered tracking of a lead.
+type Deal struct {
+	Status       DealStatus // DealNew until someone moves it on
+	Owner        string
+	NextAction   string    // What's next, e.g. "call back about the lease"
+	NextActionAt time.Time // When it's due; zero for none
+	UpdatedAt    time.Time // When the deal was last changed; zero if never
+}
+
+// Due reports whether the next action is due by t.
+func (d Deal) Due(t time.Time) bool {
+	return !d.NextActionAt.IsZero() && !d.NextActionAt.After(t)
+}
+
+// LeadNote is a timestamped note on a lead.
+type LeadNote struct {
+	ID        string
+	ABN       string
+	Note      string
+	Author    string
+	CreatedAt time.Time
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// DeleteBatch is one delete of leads, snapshotted so it can be undone.
+type DeleteBatch struct {
+	ID         string
+	DeletedAt  time.Time
+	Author     string
+	Filters    string // The filters that selected the leads, as the delete showed them
+	Leads      int
+	RestoredAt time.Time // Zero until undone
+}
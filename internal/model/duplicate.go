@@ -0,0 +1,226 @@
+package model
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DuplicateReason is why two leads look like the same business.
+type DuplicateReason string
+
+const (
+	DuplicateName              DuplicateReason = "same-name"         // Same normalized name
+	DuplicateTradingName       DuplicateReason = "trading-name"      // One's trading name is the other's name
+	DuplicateSharedTradingName DuplicateReason = "same-trading-name" // Both trade under the same name
+	DuplicateDomain            DuplicateReason = "same-domain"       // Same website domain
+	DuplicatePhone             DuplicateReason = "same-phone"        // Same phone number
+)
+
+// DuplicatePair is two leads that look like the same business, A < B.
+type DuplicatePair struct {
+	A, B    string
+	Reasons []DuplicateReason
+}
+
+// DuplicateGroup is leads linked by duplicate pairs, for review together.
+type DuplicateGroup struct {
+	ABNs     []string
+	Reasons  []DuplicateReason    // Every reason linking any two of them
+	Rejected []DuplicateRejection // Pairs within the group marked as not duplicates
+}
+
+// DuplicateRejection records that two leads were reviewed and aren't the
+// same business, so they're no longer offered as duplicates.
+type DuplicateRejection struct {
+	A, B       string
+	Author     string
+	RejectedAt time.Time
+}
+
+// OrderedPair returns a and b in the order pairs are kept in.
+func OrderedPair(a, b string) (string, string) {
+	if b < a {
+		return b, a
+	}
+	return a, b
+}
+
+// GroupDuplicates joins pairs that share a lead into groups, each sorted by
+// ABN, the groups in order of their first ABN. Two leads marked as not
+// duplicates can still end up in a group through a third, so each group
+// carries the rejections between its leads.
+func GroupDuplicates(pairs []DuplicatePair, rejections []DuplicateRejection) []DuplicateGroup {
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(abn string) string {
+		if p, ok := parent[abn]; ok && p != abn {
+			root := find(p)
+			parent[abn] = root
+			return root
+		}
+		parent[abn] = abn
+		return abn
+	}
+	for _, p := range pairs {
+		a, b := find(p.A), find(p.B)
+		if a != b {
+			parent[b] = a
+		}
+	}
+
+	byRoot := make(map[string]*DuplicateGroup)
+	for _, p := range pairs {
+		root := find(p.A)
+		g := byRoot[root]
+		if g == nil {
+			g = &DuplicateGroup{}
+			byRoot[root] = g
+		}
+		for _, abn := range []string{p.A, p.B} {
+			if !slices.Contains(g.ABNs, abn) {
+				g.ABNs = append(g.ABNs, abn)
+			}
+		}
+		for _, r := range p.Reasons {
+			if !slices.Contains(g.Reasons, r) {
+				g.Reasons = append(g.Reasons, r)
+			}
+		}
+	}
+
+	for _, rj := range rejections {
+		if _, ok := parent[rj.A]; !ok {
+			continue
+		}
+		if _, ok := parent[rj.B]; !ok {
+			continue
+		}
+		if root := find(rj.A); root == find(rj.B) {
+			byRoot[root].Rejected = append(byRoot[root].Rejected, rj)
+		}
+	}
+
+	groups := make([]DuplicateGroup, 0, len(byRoot))
+	for _, g := range byRoot {
+		slices.Sort(g.ABNs)
+		groups = append(groups, *g)
+	}
+	slices.SortFunc(groups, func(a, b DuplicateGroup) int { return strings.Compare(a.ABNs[0], b.ABNs[0]) })
+	return groups
+}
+
+// MergePlan splits the group's other leads into those to merge into keep and
+// those left apart, for each the lead it was marked as not a duplicate of.
+// A lead joins the merge only if it wasn't rejected against keep or any lead
+// already merging, taken in ABN order.
+func (g DuplicateGroup) MergePlan(keep string) (merge []string, apart map[string]string) {
+	rejectedWith := func(abn string, chosen []string) string {
+		for _, rj := range g.Rejected {
+			if rj.A == abn && slices.Contains(chosen, rj.B) {
+				return rj.B
+			}
+			if rj.B == abn && slices.Contains(chosen, rj.A) {
+				return rj.A
+			}
+		}
+		return ""
+	}
+	chosen := []string{keep}
+	for _, abn := range g.ABNs {
+		if abn == keep {
+			continue
+		}
+		if other := rejectedWith(abn, chosen); other != "" {
+			if apart == nil {
+				apart = make(map[string]string)
+			}
+			apart[abn] = other
+			continue
+		}
+		chosen = append(chosen, abn)
+		merge = append(merge, abn)
+	}
+	return merge, apart
+}
+
+// SuggestSurvivor picks which of a group's leads a merge should keep: a
+// current entity over a cancelled one, then the most sources, then the
+// oldest registration.
+func SuggestSurvivor(leads []Lead) string {
+	if len(leads) == 0 {
+		return ""
+	}
+	best := leads[0]
+	for _, l := range leads[1:] {
+		switch {
+		case l.IsCurrentEntity != best.IsCurrentEntity:
+			if l.IsCurrentEntity {
+				best = l
+			}
+		case len(l.Sources) != len(best.Sources):
+			if len(l.Sources) > len(best.Sources) {
+				best = l
+			}
+		case !l.RegistrationDate.IsZero() && (best.RegistrationDate.IsZero() || l.RegistrationDate.Before(best.RegistrationDate)):
+			best = l
+		}
+	}
+	return best.ABN
+}
+
+// sharedHosts host many businesses' pages, so sharing one says nothing.
+var sharedHosts = map[string]bool{
+	"facebook.com": true, "instagram.com": true, "linkedin.com": true, "google.com": true,
+	"business.site": true, "yellowpages.com.au": true, "truelocal.com.au": true,
+	"wixsite.com": true, "squarespace.com": true, "wordpress.com": true,
+}
+
+// WebsiteDomain returns the host of a website URL, lower case and without
+// www. The URL needn't have a scheme.
+func WebsiteDomain(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// DuplicateDomainKey is the domain duplicate detection compares, or "" for
+// none or one many businesses share. A site on a shared host's subdomain,
+// like acme.wixsite.com, is the business's own and counts.
+func DuplicateDomainKey(rawURL string) string {
+	d := WebsiteDomain(rawURL)
+	if sharedHosts[d] {
+		return ""
+	}
+	return d
+}
+
+// NormalizePhone reduces an Australian phone number to its national digits,
+// e.g. "+61 3 9555 1234" and "(03) 9555 1234" to 0395551234. It returns ""
+// for anything too short to be a full number.
+func NormalizePhone(raw string) string {
+	var digits strings.Builder
+	for _, r := range raw {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	if strings.HasPrefix(d, "61") && len(d) == 11 {
+		d = "0" + d[2:]
+	}
+	if len(d) < 8 {
+		return ""
+	}
+	return d
+}
@@ -0,0 +1,195 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EntityTypeCode is the ABR entityTypeCode, e.g. PRV, PUB, IND, DTT.
+type EntityTypeCode string
+
+const (
+	EntityPrivateCompany     EntityTypeCode = "PRV"
+	EntityPublicCompany      EntityTypeCode = "PUB"
+	EntityIndividual         EntityTypeCode = "IND"
+	EntityFamilyPartnership  EntityTypeCode = "FPT"
+	EntityOtherPartnership   EntityTypeCode = "PTR"
+	EntityLimitedPartnership EntityTypeCode = "LPT"
+	EntityDiscretionaryTrust EntityTypeCode = "DTT"
+	EntityFixedTrust         EntityTypeCode = "FXT"
+	EntityOtherIncorporated  EntityTypeCode = "OIE"
+	EntityCoOperative        EntityTypeCode = "COP"
+)
+
+// EntityKind groups entity types for filtering.
+type EntityKind string
+
+const (
+	KindPrivateCompany EntityKind = "private-company"
+	KindPublicCompany  EntityKind = "public-company"
+	KindIndividual     EntityKind = "individual"
+	KindPartnership    EntityKind = "partnership"
+	KindTrust          EntityKind = "trust"
+	KindSuperFund      EntityKind = "super-fund"
+	KindGovernment     EntityKind = "government"
+	KindOther          EntityKind = "other"
+)
+
+type entityTypeInfo struct {
+	Description string
+	Kind        EntityKind
+}
+
+// entityTypes is the one classification table every entity filter uses.
+// Private companies and partnerships are the only kinds we treat as private,
+// investable businesses.
+var entityTypes = map[EntityTypeCode]entityTypeInfo{
+	"PRV": {"Australian Private Company", KindPrivateCompany},
+	"PUB": {"Australian Public Company", KindPublicCompany},
+	"IND": {"Individual/Sole Trader", KindIndividual},
+	"FPT": {"Family Partnership", KindPartnership},
+	"PTR": {"Other Partnership", KindPartnership},
+	"LPT": {"Limited Partnership", KindPartnership},
+	"CUT": {"Corporate Unit Trust", KindTrust},
+	"CMT": {"Cash Management Trust", KindTrust},
+	"DST": {"Discretionary Services Management Trust", KindTrust},
+	"DTT": {"Discretionary Trading Trust", KindTrust},
+	"DIT": {"Discretionary Investment Trust", KindTrust},
+	"FHS": {"First Home Saver Accounts Trust", KindTrust},
+	"FUT": {"Fixed Unit Trust", KindTrust},
+	"FXT": {"Fixed Trust", KindTrust},
+	"HYT": {"Hybrid Trust", KindTrust},
+	"PQT": {"Unlisted Public Unit Trust", KindTrust},
+	"PTT": {"Public Trading trust", KindTrust},
+	"PUT": {"Listed Public Unit Trust", KindTrust},
+	"TRT": {"Other trust", KindTrust},
+	"ADF": {"Approved Deposit Fund", KindSuperFund},
+	"ARF": {"APRA Regulated Fund (Fund Type Unknown)", KindSuperFund},
+	"NPF": {"APRA Regulated Non-Public Offer Fund", KindSuperFund},
+	"NRF": {"Non-Regulated Superannuation Fund", KindSuperFund},
+	"POF": {"APRA Regulated Public Offer Fund", KindSuperFund},
+	"PST": {"Pooled Superannuation Trust", KindSuperFund},
+	"SAF": {"Small APRA Fund", KindSuperFund},
+	"SMF": {"ATO Regulated Self-Managed Superannuation Fund", KindSuperFund},
+	"SUP": {"Super fund", KindSuperFund},
+	"COP": {"Co-operative", KindOther},
+	"DES": {"Deceased Estate", KindOther},
+	"DIP": {"Diplomatic/Consulate Body or High Commissioner", KindOther},
+	"OIE": {"Other Incorporated Entity", KindOther},
+	"PDF": {"Pooled Development Fund", KindOther},
+	"STR": {"Strata-title", KindOther},
+	"UIE": {"Other Unincorporated Entity", KindOther},
+}
+
+// Government entity codes are a level prefix on a shared set of suffixes,
+// e.g. CGE (Commonwealth Government Entity), LGA, SGT.
+func init() {
+	levels := map[string]string{"C": "Commonwealth", "L": "Local", "S": "State", "T": "Territory"}
+	suffixes := map[string]string{
+		"CB": "Public Company", "CC": "Co-operative", "CL": "Limited Partnership",
+		"CN": "Other Unincorporated Entity", "CO": "Other Incorporated Entity",
+		"CP": "Pooled Development Fund", "CR": "Private Company", "CS": "Strata Title",
+		"CT": "Public Trading Trust", "CU": "Corporate Unit Trust", "GA": "Statutory Authority",
+		"GC": "Company", "GE": "Entity", "GP": "Partnership", "GS": "Super Fund", "GT": "Trust",
+		"SA": "APRA Regulated Public Sector Fund", "SP": "APRA Regulated Public Sector Scheme",
+		"SS": "Non-Regulated Super Fund", "TC": "Cash Management Trust",
+		"TD": "Discretionary Services Management Trust", "TF": "Fixed Trust", "TH": "Hybrid Trust",
+		"TI": "Discretionary Investment Trust", "TL": "Listed Public Unit Trust",
+		"TQ": "Unlisted Public Unit Trust", "TT": "Discretionary Trading Trust", "TU": "Fixed Unit Trust",
+	}
+	for prefix, level := range levels {
+		for suffix, desc := range suffixes {
+			entityTypes[EntityTypeCode(prefix+suffix)] = entityTypeInfo{level + " Government " + desc, KindGovernment}
+		}
+	}
+}
+
+// ParseEntityTypeCode normalizes an ABR code, returning "" if it is unknown.
+func ParseEntityTypeCode(raw string) EntityTypeCode {
+	code := EntityTypeCode(strings.ToUpper(strings.TrimSpace(raw)))
+	if _, ok := entityTypes[code]; !ok {
+		return ""
+	}
+	return code
+}
+
+// EntityTypeCodeFor maps an ABR entity description back onto its code, for
+// leads stored before we captured the code.
+func EntityTypeCodeFor(description string) EntityTypeCode {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return ""
+	}
+	for code, info := range entityTypes {
+		if strings.EqualFold(info.Description, description) {
+			return code
+		}
+	}
+	return ""
+}
+
+func (c EntityTypeCode) Description() string {
+	return entityTypes[c].Description
+}
+
+// Kind returns the code's kind, or "" if the code is unknown.
+func (c EntityTypeCode) Kind() EntityKind {
+	return entityTypes[c].Kind
+}
+
+func (c EntityTypeCode) IsPrivate() bool {
+	kind := c.Kind()
+	return kind == KindPrivateCompany || kind == KindPartnership
+}
+
+// PrivateEntityTypeCodes lists every code IsPrivate accepts, for SQL filters.
+func PrivateEntityTypeCodes() []EntityTypeCode {
+	var codes []EntityTypeCode
+	for code := range entityTypes {
+		if code.IsPrivate() {
+			codes = append(codes, code)
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// ParseEntityTypes parses a comma-separated list of codes (PRV,DTT) and kinds
+// (trust,government) into the codes they cover.
+func ParseEntityTypes(raw string) ([]EntityTypeCode, error) {
+	seen := make(map[EntityTypeCode]bool)
+	var codes []EntityTypeCode
+	add := func(c EntityTypeCode) {
+		if !seen[c] {
+			seen[c] = true
+			codes = append(codes, c)
+		}
+	}
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if code := ParseEntityTypeCode(part); code != "" {
+			add(code)
+			continue
+		}
+		kind := EntityKind(strings.ToLower(part))
+		var matched []EntityTypeCode
+		for code, info := range entityTypes {
+			if info.Kind == kind {
+				matched = append(matched, code)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("unknown entity type %q", part)
+		}
+		sort.Slice(matched, func(i, j int) bool { return matched[i] < matched[j] })
+		for _, code := range matched {
+			add(code)
+		}
+	}
+	return codes, nil
+}
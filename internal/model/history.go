@@ -0,0 +1,64 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// LeadChange is a tracked field of a stored lead changing on a later save.
+type LeadChange struct {
+	ABN       string
+	Name      string // The lead's current name
+	RunID     string
+	Field     string
+	OldValue  string
+	NewValue  string
+	ChangedAt time.Time
+}
+
+// ChangeKind groups changes for the changes report.
+type ChangeKind string
+
+const (
+	ChangeDeregistered  ChangeKind = "Deregistered"
+	ChangeReregistered  ChangeKind = "Reregistered"
+	ChangeGSTCancelled  ChangeKind = "GST cancelled"
+	ChangeGSTRegistered ChangeKind = "GST registered"
+	ChangeName          ChangeKind = "Name change"
+	ChangeAddress       ChangeKind = "Address change"
+	ChangeContact       ChangeKind = "Contact change"
+	ChangeOther         ChangeKind = "Other"
+)
+
+// ChangeKinds lists the kinds in report order, most significant first.
+var ChangeKinds = []ChangeKind{
+	ChangeDeregistered, ChangeGSTCancelled, ChangeName, ChangeAddress,
+	ChangeContact, ChangeReregistered, ChangeGSTRegistered, ChangeOther,
+}
+
+func (c LeadChange) Kind() ChangeKind {
+	switch c.Field {
+	case "entity_status":
+		if strings.EqualFold(c.NewValue, "Active") {
+			return ChangeReregistered
+		}
+		return ChangeDeregistered
+	case "is_current_entity":
+		if c.NewValue == "true" {
+			return ChangeReregistered
+		}
+		return ChangeDeregistered
+	case "gst_registered":
+		if c.NewValue == "true" {
+			return ChangeGSTRegistered
+		}
+		return ChangeGSTCancelled
+	case "name", "main_trading_name":
+		return ChangeName
+	case "state", "postcode":
+		return ChangeAddress
+	case "phone", "email", "business_url":
+		return ChangeContact
+	}
+	return ChangeOther
+}
@@ -1,8 +1,13 @@
 package model
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/shanehull/sourcerer/internal/anzsic"
+	"github.com/shanehull/sourcerer/internal/geo"
 )
 
 type Lead struct {
@@ -10,10 +15,18 @@ type Lead struct {
 	Name             string
 	Category         string
 	Sources          []string
-	EntityType       string
+	Provenance       []SourceRecord
+	Assertions       []FieldValue
+	EntityType       string         // ABR entity description
+	EntityTypeCode   EntityTypeCode // ABR entity type code
 	EntityStatus     string
 	State            string
 	Postcode         string
+	LGA              string  // Local government area, from the postcode data
+	Region           string  // ABS SA4 region, from the postcode data
+	Latitude         float64 // Postcode centroid
+	Longitude        float64 // Postcode centroid
+	StateMismatch    bool    // State disagrees with the state the postcode belongs to
 	RegistrationDate time.Time
 	IsGSTRegistered  bool
 	ACN              string    // ASIC number
@@ -24,9 +37,74 @@ type Lead struct {
 	Email            string    // Contact email
 	BusinessURL      string    // Actual business website URL
 	FoundAtURL       string    // URL where we found the lead (e.g., northlink.org.au/...)
+	SearchKeyword    string    // ABR search keyword that turned the lead up
+	Website          WebsiteSignals
+	Industry         anzsic.Classification
+	IsNFP            bool       // ABR lists charity tax concessions or ACNC registration
+	Charity          *Charity   // ACNC register entry, nil if not a registered charity
+	Tax              *TaxRecord // Latest ATO tax transparency record, nil if not reported
+	Score            Score
+	Criteria         CriteriaResults
+	Deal             Deal      // The team's tracking; SaveLead never writes it
+	EnrichedAt       time.Time // When the ABR last confirmed the lead
+	UpdatedAt        time.Time // When the lead was last saved
 	EnrichmentError  error
 }
 
+// SourceRecord is one source's listing of a lead.
+type SourceRecord struct {
+	Source     string
+	FoundAtURL string
+	Category   string // The source's own category
+	RawName    string // Name as scraped, before ABR enrichment
+	FirstSeen  time.Time
+	LastSeen   time.Time
+}
+
+// Charity is an entry in the ACNC charity register.
+type Charity struct {
+	ABN                string
+	LegalName          string
+	Size               string // Small, Medium or Large
+	ResponsiblePersons int
+	RegistrationDate   time.Time
+	Website            string
+}
+
+// WebsiteSignals are digital-maturity signals read from the lead's homepage.
+type WebsiteSignals struct {
+	CheckedAt     time.Time // Zero if the site hasn't been inspected
+	Platform      string    // CMS or site builder, e.g. WordPress, Wix
+	HTTPS         bool
+	CopyrightYear int // Latest copyright year on the page, 0 if none
+	HasStore      bool
+	HasCareers    bool
+	PageBytes     int
+	Title         string // <title>, kept for industry classification
+	Description   string // Meta description
+}
+
+// WebsiteFilter selects leads by their website signals. The zero value matches
+// every lead.
+type WebsiteFilter struct {
+	StaleBefore int      // Copyright year older than this
+	Platforms   []string // Any of these platforms
+	NoHTTPS     bool     // Site isn't served over HTTPS
+}
+
+func (f WebsiteFilter) IsZero() bool {
+	return f.StaleBefore == 0 && len(f.Platforms) == 0 && !f.NoHTTPS
+}
+
+// InIndustries reports whether the lead is classified under any of the given
+// ANZSIC division, subdivision or class codes. No codes matches every lead.
+func (l *Lead) InIndustries(codes []string) bool {
+	if len(codes) == 0 {
+		return true
+	}
+	return l.Industry.Matches(codes)
+}
+
 func (l *Lead) AgeYears() int {
 	if l.RegistrationDate.IsZero() {
 		return 0
@@ -45,30 +123,51 @@ func (l *Lead) IsVeteran(minAge int) bool {
 	return l.AgeYears() >= minAge
 }
 
+// IsPrivateEntity reports whether the lead is a private company or partnership,
+// per the entity type table. Charities and NFPs never are, whatever their
+// legal form, as they can't be acquired.
 func (l *Lead) IsPrivateEntity() bool {
-	lowerType := strings.ToLower(l.EntityType)
-
-	if strings.Contains(lowerType, "public company") {
+	if l.IsCharityOrNFP() {
 		return false
 	}
-
-	if strings.Contains(lowerType, "government") {
-		return false
+	code := l.EntityTypeCode
+	if code == "" {
+		code = EntityTypeCodeFor(l.EntityType)
 	}
+	return code.IsPrivate()
+}
 
-	if strings.Contains(lowerType, "sole trader") || strings.Contains(lowerType, "individual") {
-		return false
-	}
+// Score is a lead's priority from the scoring rules.
+type Score struct {
+	Total     float64            // 0-100
+	Breakdown map[string]float64 // Points per rule, summing to Total
+	Weights   string             // Hash of the weights that produced it
+}
 
-	if strings.Contains(lowerType, "other incorporated entity") {
-		return false
-	}
+// TaxRecord is one year of the ATO Corporate Tax Transparency report. Amounts
+// are whole dollars; nil where the ATO left them blank.
+type TaxRecord struct {
+	ABN           string
+	IncomeYear    int // Year the income year ends, e.g. 2023 for 2022-23
+	Name          string
+	TotalIncome   *int64
+	TaxableIncome *int64
+	TaxPayable    *int64
+}
 
-	if strings.Contains(lowerType, "trust") {
-		return false
+// WithinIncome reports whether the lead's latest reported total income is
+// within [min, max]; zero bounds are open. Leads the ATO doesn't report on
+// (private companies below its reporting threshold) always pass.
+func (l *Lead) WithinIncome(min, max int64) bool {
+	if l.Tax == nil || l.Tax.TotalIncome == nil {
+		return true
 	}
+	income := *l.Tax.TotalIncome
+	return (min == 0 || income >= min) && (max == 0 || income <= max)
+}
 
-	return true
+func (l *Lead) IsCharityOrNFP() bool {
+	return l.Charity != nil || l.IsNFP
 }
 
 func (l *Lead) IsInvestable(allowedStates []string, allowedPostcodes []PostcodeRange) bool {
@@ -82,6 +181,12 @@ func (l *Lead) IsInvestable(allowedStates []string, allowedPostcodes []PostcodeR
 		return false
 	}
 	
+	return l.InLocation(allowedStates, allowedPostcodes)
+}
+
+// InLocation reports whether the lead is in one of the states and postcode
+// ranges. None of either means no restriction.
+func (l *Lead) InLocation(allowedStates []string, allowedPostcodes []PostcodeRange) bool {
 	if len(allowedStates) > 0 {
 		found := false
 		for _, s := range allowedStates {
@@ -94,6 +199,117 @@ func (l *Lead) IsInvestable(allowedStates []string, allowedPostcodes []PostcodeR
 			return false
 		}
 	}
+
+	if len(allowedPostcodes) > 0 {
+		postcode, err := strconv.Atoi(strings.TrimSpace(l.Postcode))
+		if err != nil {
+			return false
+		}
+		found := false
+		for _, r := range allowedPostcodes {
+			if r.Contains(postcode) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Locate places the lead at its postcode's LGA, SA4 region and centroid,
+// reporting whether data has the postcode.
+func (l *Lead) Locate(data *geo.Dataset) bool {
+	pc, ok := data.Lookup(l.Postcode)
+	if !ok {
+		return false
+	}
+	l.LGA = pc.LGA
+	l.Region = pc.Region
+	l.Latitude = pc.Latitude
+	l.Longitude = pc.Longitude
+	return true
+}
+
+// Located reports whether the lead has been placed. Area and radius filters
+// leave out a lead that hasn't, since they can't tell where it is.
+func (l *Lead) Located() bool {
+	return l.LGA != "" || l.Region != "" || l.Latitude != 0 || l.Longitude != 0
+}
+
+// InAreas reports whether the lead is in one of the given LGAs or SA4 regions.
+// No LGAs or regions means no restriction.
+func (l *Lead) InAreas(lgas, regions []string) bool {
+	if len(lgas) == 0 && len(regions) == 0 {
+		return true
+	}
+	for _, lga := range lgas {
+		if l.LGA != "" && strings.EqualFold(l.LGA, lga) {
+			return true
+		}
+	}
+	for _, region := range regions {
+		if l.Region != "" && strings.EqualFold(l.Region, region) {
+			return true
+		}
+	}
+	return false
+}
+
+// Radius limits leads to those within Km of Center.
+type Radius struct {
+	Center geo.Point
+	Km     float64
+}
+
+// DistanceKm is the distance from the lead's postcode centroid to p, or -1 if
+// the lead has no location.
+func (l *Lead) DistanceKm(p geo.Point) float64 {
+	if l.Latitude == 0 && l.Longitude == 0 {
+		return -1
+	}
+	return geo.DistanceKm(geo.Point{Latitude: l.Latitude, Longitude: l.Longitude}, p)
+}
+
+// WithinRadius reports whether the lead is inside r. A nil radius means no
+// restriction.
+func (l *Lead) WithinRadius(r *Radius) bool {
+	if r == nil {
+		return true
+	}
+	d := l.DistanceKm(r.Center)
+	return d >= 0 && d <= r.Km
+}
+
+// MatchesWebsite reports whether the lead's website signals pass f. Leads
+// whose site hasn't been inspected only pass the zero filter.
+func (l *Lead) MatchesWebsite(f WebsiteFilter) bool {
+	if f.IsZero() {
+		return true
+	}
+	if l.Website.CheckedAt.IsZero() {
+		return false
+	}
+	if f.StaleBefore > 0 && (l.Website.CopyrightYear == 0 || l.Website.CopyrightYear >= f.StaleBefore) {
+		return false
+	}
+	if f.NoHTTPS && l.Website.HTTPS {
+		return false
+	}
+	if len(f.Platforms) > 0 {
+		found := false
+		for _, p := range f.Platforms {
+			if strings.EqualFold(l.Website.Platform, p) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
 	return true
 }
 
@@ -101,3 +317,36 @@ type PostcodeRange struct {
 	Min int
 	Max int
 }
+
+func (r PostcodeRange) Contains(postcode int) bool {
+	return postcode >= r.Min && postcode <= r.Max
+}
+
+// ParsePostcodeRanges parses "3000-3999,2000-2234,5095" into ranges. A single
+// postcode is a range of one.
+func ParsePostcodeRanges(raw string) ([]PostcodeRange, error) {
+	var ranges []PostcodeRange
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		before, after, found := strings.Cut(part, "-")
+		if !found {
+			after = before
+		}
+		min, err := strconv.Atoi(strings.TrimSpace(before))
+		if err != nil {
+			return nil, fmt.Errorf("invalid postcode range %q", part)
+		}
+		max, err := strconv.Atoi(strings.TrimSpace(after))
+		if err != nil {
+			return nil, fmt.Errorf("invalid postcode range %q", part)
+		}
+		if min > max {
+			min, max = max, min
+		}
+		ranges = append(ranges, PostcodeRange{Min: min, Max: max})
+	}
+	return ranges, nil
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// legalSuffixes are trailing words that differ between listings of the same
+// business, e.g. "Acme Pty Ltd" on the ABR and "Acme" in a directory.
+var legalSuffixes = map[string]bool{
+	"pty": true, "ltd": true, "limited": true, "proprietary": true,
+	"inc": true, "incorporated": true,
+}
+
+// NormalizeName reduces a business name to a key that survives the usual
+// differences between listings: case, punctuation, "&" for "and" and legal
+// suffixes like Pty Ltd or P/L.
+func NormalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(strings.ReplaceAll(name, "&", " and ")), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	end := len(words)
+	for end > 0 {
+		if legalSuffixes[words[end-1]] {
+			end--
+		} else if end > 1 && words[end-2] == "p" && words[end-1] == "l" {
+			end -= 2
+		} else {
+			break
+		}
+	}
+	// A name that's all suffix keeps them rather than matching everything
+	if end == 0 {
+		end = len(words)
+	}
+	return strings.Join(words[:end], " ")
+}
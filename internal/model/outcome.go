@@ -0,0 +1,44 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Outcome is what happened when the team worked a lead.
+type Outcome string
+
+const (
+	OutcomeContacted Outcome = "contacted"
+	OutcomeMeeting   Outcome = "meeting"
+	OutcomePassed    Outcome = "passed"
+	OutcomeRejected  Outcome = "rejected"
+)
+
+func ParseOutcome(raw string) (Outcome, error) {
+	switch o := Outcome(strings.ToLower(strings.TrimSpace(raw))); o {
+	case OutcomeContacted, OutcomeMeeting, OutcomePassed, OutcomeRejected:
+		return o, nil
+	}
+	return "", fmt.Errorf("unknown outcome %q (want contacted, meeting, passed or rejected)", raw)
+}
+
+// Decisive reports whether the outcome says anything about the lead's
+// quality. Contacted only means we reached out.
+func (o Outcome) Decisive() bool {
+	return o == OutcomeMeeting || o == OutcomePassed || o == OutcomeRejected
+}
+
+// Progressed reports whether a decisive outcome counts as the lead
+// progressing.
+func (o Outcome) Progressed() bool {
+	return o == OutcomeMeeting
+}
+
+type LeadOutcome struct {
+	ABN        string
+	Outcome    Outcome
+	Note       string
+	RecordedAt time.Time
+}
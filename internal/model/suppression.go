@@ -0,0 +1,91 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Suppression keeps a lead out of the pipeline and exports, e.g. one we've
+// already passed on. It matches by ABN or by a case-insensitive name pattern
+// where * matches anything.
+type Suppression struct {
+	ID          string
+	ABN         string
+	NamePattern string
+	Source      string // Only listings from this source; empty for all
+	Reason      string
+	Author      string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time // Zero never expires
+	BatchID     string    // The delete batch that added it, whose undo lifts it; empty if added directly
+}
+
+func (s Suppression) Active(now time.Time) bool {
+	return s.ExpiresAt.IsZero() || s.ExpiresAt.After(now)
+}
+
+// Matches reports whether s suppresses a listing of the lead by source.
+// Either of abn and name may be empty if it isn't known yet.
+func (s Suppression) Matches(abn, name, source string, now time.Time) bool {
+	if !s.Active(now) {
+		return false
+	}
+	if s.Source != "" && !strings.EqualFold(s.Source, source) {
+		return false
+	}
+	if s.ABN != "" && abn != "" && s.ABN == abn {
+		return true
+	}
+	return s.NamePattern != "" && name != "" && GlobMatch(s.NamePattern, name)
+}
+
+// GlobMatch matches name against a case-insensitive pattern where * matches
+// any run of characters.
+func GlobMatch(pattern, name string) bool {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(pattern)), "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	re, err := regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// Suppressions is a loaded suppression list.
+type Suppressions []Suppression
+
+// Match returns the first suppression matching the listing, if any.
+func (list Suppressions) Match(abn, name, source string, now time.Time) (Suppression, bool) {
+	for _, s := range list {
+		if s.Matches(abn, name, source, now) {
+			return s, true
+		}
+	}
+	return Suppression{}, false
+}
+
+// ParseExpiry parses a suppression expiry: a date like 2027-06-30, a number
+// of days like 90d, or a Go duration like 720h. Empty never expires.
+func ParseExpiry(raw string, now time.Time) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return now.AddDate(0, 0, n), nil
+		}
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid expiry %q (want a date like 2027-06-30 or a duration like 90d)", raw)
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// NormalizeTag is the stored form of a tag: lower case, with runs of spaces
+// as single hyphens, so "Q3 Shortlist" and "q3-shortlist" are one tag.
+func NormalizeTag(raw string) string {
+	return strings.Join(strings.Fields(strings.ToLower(raw)), "-")
+}
+
+// ParseTags parses a comma-separated list of tags, normalised.
+func ParseTags(raw string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(raw, ",") {
+		tag := NormalizeTag(part)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// TagCount is a tag in use, and how many leads carry it.
+type TagCount struct {
+	Tag   string
+	Leads int
+}
+
+// LeadList is a named, static list of leads, e.g. "Q3 shortlist". Its
+// members are the leads added to it, whatever they look like since.
+type LeadList struct {
+	Name      string
+	Author    string
+	CreatedAt time.Time
+	Leads     int
+}
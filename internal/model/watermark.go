@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// ExportWatermark marks a named hand-off, like the weekly BD export, so the
+// next export under the name carries only what changed since.
+type ExportWatermark struct {
+	Name       string
+	ExportedAt time.Time
+	RunID      string
+	Leads      int // Leads handed off and still in
+}
+
+// ExportChange is why a delta export carries a lead.
+type ExportChange string
+
+const (
+	ExportNew         ExportChange = "new"         // Never handed off
+	ExportUpdated     ExportChange = "updated"     // A tracked field changed since the hand-off
+	ExportReactivated ExportChange = "reactivated" // Handed off, dropped out, and back in
+	ExportDropped     ExportChange = "dropped"     // Handed off, and since unmatched, suppressed or deleted
+)
+
+// InExport reports whether a lead exported with this change is in the
+// hand-off afterwards.
+func (c ExportChange) InExport() bool {
+	return c != ExportDropped
+}
@@ -0,0 +1,172 @@
+package precedence
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// precedence.json is the default config; -precedence replaces it.
+//
+//go:embed precedence.json
+var defaultRules []byte
+
+// Tie-breaks between asserters a rule doesn't rank
+const (
+	Newest = "newest"
+	Oldest = "oldest"
+)
+
+// Rule picks a field's canonical value: the value from the first asserter in
+// Prefer that has one, otherwise the newest or oldest assertion.
+type Rule struct {
+	Prefer []string `json:"prefer"`
+	Then   string   `json:"then"`
+}
+
+type Config struct {
+	Default Rule            `json:"default"`
+	Fields  map[string]Rule `json:"fields"`
+}
+
+func Default() Config {
+	cfg, err := Parse(defaultRules)
+	if err != nil {
+		panic(fmt.Sprintf("precedence: embedded rules are invalid: %v", err))
+	}
+	return cfg
+}
+
+func LoadFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("could not read precedence config: %w", err)
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid precedence config: %w", err)
+	}
+	if err := cfg.Default.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid precedence config: default: %w", err)
+	}
+	for field, rule := range cfg.Fields {
+		if !model.IsAssertedField(field) {
+			return Config{}, fmt.Errorf("invalid precedence config: unknown field %q", field)
+		}
+		if err := rule.validate(); err != nil {
+			return Config{}, fmt.Errorf("invalid precedence config: %s: %w", field, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (r Rule) validate() error {
+	if r.Then != Newest && r.Then != Oldest {
+		return fmt.Errorf("then must be %q or %q, not %q", Newest, Oldest, r.Then)
+	}
+	return nil
+}
+
+// Rule returns the rule for a field, falling back to the default.
+func (c Config) Rule(field string) Rule {
+	if r, ok := c.Fields[field]; ok {
+		return r
+	}
+	return c.Default
+}
+
+// Pick returns the canonical value among a field's assertions, false if there
+// are none.
+func (r Rule) Pick(values []model.FieldValue) (model.FieldValue, bool) {
+	if len(values) == 0 {
+		return model.FieldValue{}, false
+	}
+	for _, asserter := range r.Prefer {
+		for _, v := range values {
+			if strings.EqualFold(v.Asserter, asserter) {
+				return v, true
+			}
+		}
+	}
+
+	best := values[0]
+	for _, v := range values[1:] {
+		if r.Then == Oldest && v.AssertedAt.Before(best.AssertedAt) ||
+			r.Then == Newest && v.AssertedAt.After(best.AssertedAt) {
+			best = v
+		}
+	}
+	return best, true
+}
+
+type Resolver struct {
+	cfg Config
+}
+
+func NewResolver(cfg Config) *Resolver {
+	return &Resolver{cfg: cfg}
+}
+
+func (r *Resolver) Rule(field string) Rule {
+	return r.cfg.Rule(field)
+}
+
+// Merge combines stored assertions with newer ones, keeping one value per
+// field and asserter. A value asserted again keeps its original time.
+func Merge(stored, recent []model.FieldValue) []model.FieldValue {
+	type key struct{ field, asserter string }
+	index := make(map[key]int)
+	var out []model.FieldValue
+	for _, list := range [][]model.FieldValue{stored, recent} {
+		for _, v := range list {
+			k := key{v.Field, v.Asserter}
+			i, ok := index[k]
+			if !ok {
+				index[k] = len(out)
+				out = append(out, v)
+				continue
+			}
+			if out[i].Value != v.Value {
+				out[i] = v
+			}
+		}
+	}
+	return out
+}
+
+// Resolve returns the canonical value of each field with assertions.
+func (r *Resolver) Resolve(values []model.FieldValue) map[string]model.FieldValue {
+	byField := make(map[string][]model.FieldValue)
+	for _, v := range values {
+		byField[v.Field] = append(byField[v.Field], v)
+	}
+	out := make(map[string]model.FieldValue)
+	for field, vs := range byField {
+		// Stable input order keeps ties deterministic
+		sort.SliceStable(vs, func(i, j int) bool { return vs[i].Asserter < vs[j].Asserter })
+		if v, ok := r.cfg.Rule(field).Pick(vs); ok {
+			out[field] = v
+		}
+	}
+	return out
+}
+
+// Apply sets the lead's fields to their canonical values, given what's
+// already stored for it and what was asserted this run.
+func (r *Resolver) Apply(l *model.Lead, stored []model.FieldValue) {
+	for field, v := range r.Resolve(Merge(stored, l.Assertions)) {
+		l.SetField(field, v.Value)
+	}
+}
@@ -0,0 +1,257 @@
+package rank
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// Feature is one input to the model. Inputs are standardized with the training
+// Mean and Std, so Weight is the change in log-odds per standard deviation and
+// weights are comparable across features.
+type Feature struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+	Mean   float64 `json:"mean"`
+	Std    float64 `json:"std"`
+}
+
+// Model is a logistic regression over lead features.
+type Model struct {
+	ID        string    `json:"id"`
+	TrainedAt time.Time `json:"trained_at"`
+	Samples   int       `json:"samples"`
+	Positives int       `json:"positives"`
+	Intercept float64   `json:"intercept"`
+	Features  []Feature `json:"features"`
+}
+
+// Sample is a reviewed lead and whether it progressed.
+type Sample struct {
+	Lead       model.Lead
+	Progressed bool
+}
+
+// Contribution is a feature's share of one lead's log-odds.
+type Contribution struct {
+	Feature string
+	Value   float64 // Raw feature value
+	LogOdds float64
+}
+
+const (
+	iterations   = 2000
+	learningRate = 0.5
+	// L2 penalty keeps weights sane with few samples and many one-hot features
+	l2 = 0.05
+)
+
+// Features extracts the model inputs for a lead: age, entity type, category,
+// state, sources and website signals. Categorical values are one-hot, named
+// "state=VIC" and so on.
+func Features(l *model.Lead, now time.Time) map[string]float64 {
+	f := map[string]float64{
+		"age_years": float64(l.AgeYears()),
+	}
+
+	code := l.EntityTypeCode
+	if code == "" {
+		code = model.EntityTypeCodeFor(l.EntityType)
+	}
+	if code != "" {
+		f["entity_type="+string(code)] = 1
+	}
+	if c := strings.ToLower(strings.TrimSpace(l.Category)); c != "" {
+		f["category="+c] = 1
+	}
+	if s := strings.ToUpper(strings.TrimSpace(l.State)); s != "" {
+		f["state="+s] = 1
+	}
+
+	distinct := make(map[string]bool)
+	for _, s := range l.Sources {
+		if s = strings.TrimSpace(s); s != "" {
+			distinct[s] = true
+			f["source="+s] = 1
+		}
+	}
+	f["source_count"] = float64(len(distinct))
+
+	if l.BusinessURL != "" {
+		f["web_has_site"] = 1
+	}
+	if w := l.Website; !w.CheckedAt.IsZero() {
+		f["web_checked"] = 1
+		if w.HTTPS {
+			f["web_https"] = 1
+		}
+		if w.HasStore {
+			f["web_has_store"] = 1
+		}
+		if w.HasCareers {
+			f["web_has_careers"] = 1
+		}
+		if w.CopyrightYear > 0 {
+			f["web_copyright_age"] = float64(now.Year() - w.CopyrightYear)
+		}
+		if w.Platform != "" {
+			f["web_platform="+strings.ToLower(w.Platform)] = 1
+		}
+	}
+	return f
+}
+
+// Labels turns outcome history into training labels: each lead's latest
+// decisive outcome. Leads only ever contacted are left out.
+func Labels(outcomes []model.LeadOutcome) map[string]bool {
+	sorted := append([]model.LeadOutcome(nil), outcomes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].RecordedAt.Before(sorted[j].RecordedAt) })
+
+	labels := make(map[string]bool)
+	for _, o := range sorted {
+		if o.Outcome.Decisive() {
+			labels[o.ABN] = o.Outcome.Progressed()
+		}
+	}
+	return labels
+}
+
+// Train fits a model by batch gradient descent on the L2-penalized log-loss.
+func Train(samples []Sample, now time.Time) (*Model, error) {
+	positives := 0
+	for _, s := range samples {
+		if s.Progressed {
+			positives++
+		}
+	}
+	if positives == 0 || positives == len(samples) {
+		return nil, fmt.Errorf("need both progressed and non-progressed leads to train (have %d of %d progressed)", positives, len(samples))
+	}
+
+	raw := make([]map[string]float64, len(samples))
+	names := make(map[string]bool)
+	for i, s := range samples {
+		raw[i] = Features(&s.Lead, now)
+		for name := range raw[i] {
+			names[name] = true
+		}
+	}
+
+	// Standardize, dropping features that never vary in the training set
+	var features []Feature
+	for name := range names {
+		var sum, sumSq float64
+		for _, r := range raw {
+			sum += r[name]
+			sumSq += r[name] * r[name]
+		}
+		n := float64(len(raw))
+		mean := sum / n
+		std := math.Sqrt(sumSq/n - mean*mean)
+		if std < 1e-9 {
+			continue
+		}
+		features = append(features, Feature{Name: name, Mean: mean, Std: std})
+	}
+	sort.Slice(features, func(i, j int) bool { return features[i].Name < features[j].Name })
+
+	x := make([][]float64, len(samples))
+	y := make([]float64, len(samples))
+	for i, r := range raw {
+		x[i] = make([]float64, len(features))
+		for j, f := range features {
+			x[i][j] = (r[f.Name] - f.Mean) / f.Std
+		}
+		if samples[i].Progressed {
+			y[i] = 1
+		}
+	}
+
+	weights := make([]float64, len(features))
+	var intercept float64
+	n := float64(len(samples))
+	for iter := 0; iter < iterations; iter++ {
+		grad := make([]float64, len(features))
+		var gradIntercept float64
+		for i := range x {
+			err := sigmoid(intercept+dot(weights, x[i])) - y[i]
+			gradIntercept += err
+			for j, v := range x[i] {
+				grad[j] += err * v
+			}
+		}
+		intercept -= learningRate * gradIntercept / n
+		for j := range weights {
+			weights[j] -= learningRate * (grad[j]/n + l2*weights[j])
+		}
+	}
+
+	for j := range features {
+		features[j].Weight = weights[j]
+	}
+	m := &Model{
+		TrainedAt: now,
+		Samples:   len(samples),
+		Positives: positives,
+		Intercept: intercept,
+		Features:  features,
+	}
+	m.ID = m.hash()
+	return m, nil
+}
+
+func (m *Model) hash() string {
+	data, _ := json.Marshal(struct {
+		Intercept float64
+		Features  []Feature
+	}{m.Intercept, m.Features})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// Predict returns the probability the lead progresses.
+func (m *Model) Predict(l *model.Lead, now time.Time) float64 {
+	logOdds := m.Intercept
+	for _, c := range m.Explain(l, now) {
+		logOdds += c.LogOdds
+	}
+	return sigmoid(logOdds)
+}
+
+// Explain breaks a lead's log-odds down by feature, largest effect first.
+func (m *Model) Explain(l *model.Lead, now time.Time) []Contribution {
+	raw := Features(l, now)
+	out := make([]Contribution, 0, len(m.Features))
+	for _, f := range m.Features {
+		v := raw[f.Name]
+		out = append(out, Contribution{Feature: f.Name, Value: v, LogOdds: f.Weight * (v - f.Mean) / f.Std})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return math.Abs(out[i].LogOdds) > math.Abs(out[j].LogOdds) })
+	return out
+}
+
+// Coefficients returns the features by absolute weight, largest first.
+func (m *Model) Coefficients() []Feature {
+	out := append([]Feature(nil), m.Features...)
+	sort.SliceStable(out, func(i, j int) bool { return math.Abs(out[i].Weight) > math.Abs(out[j].Weight) })
+	return out
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// ParseACNC reads the ACNC charity register CSV (datadotgov_main.csv from
+// data.gov.au). Columns are matched by name, so extra and reordered columns
+// are fine.
+func ParseACNC(r io.Reader) ([]model.Charity, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := columnIndex(header)
+	for _, required := range []string{"abn", "charity_size"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("ACNC register missing %q column", required)
+		}
+	}
+
+	var charities []model.Charity
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := getter(cols, record)
+
+		abn := NormalizeABN(get("abn"))
+		if abn == "" {
+			continue
+		}
+		persons, _ := strconv.Atoi(get("number_of_responsible_persons"))
+		charities = append(charities, model.Charity{
+			ABN:                abn,
+			LegalName:          get("charity_legal_name"),
+			Size:               get("charity_size"),
+			ResponsiblePersons: persons,
+			RegistrationDate:   parseDate(get("registration_date")),
+			Website:            get("charity_website"),
+		})
+	}
+	return charities, nil
+}
+
+// NormalizeABN strips spacing from an ABN, returning "" if it isn't 11 digits.
+func NormalizeABN(raw string) string {
+	abn := strings.ReplaceAll(strings.TrimSpace(raw), " ", "")
+	if len(abn) != 11 {
+		return ""
+	}
+	if _, err := strconv.ParseUint(abn, 10, 64); err != nil {
+		return ""
+	}
+	return abn
+}
+
+// columnIndex maps normalized header names ("Total income $" becomes
+// total_income) to their position.
+func columnIndex(header []string) map[string]int {
+	cols := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimPrefix(name, "\ufeff"))
+		name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}), "_")
+		if _, ok := cols[name]; !ok {
+			cols[name] = i
+		}
+	}
+	return cols
+}
+
+func getter(cols map[string]int, record []string) func(string) string {
+	return func(key string) string {
+		if idx, ok := cols[key]; ok && idx < len(record) {
+			return strings.TrimSpace(record[idx])
+		}
+		return ""
+	}
+}
+
+// parseDate accepts the date formats the government registers use.
+func parseDate(raw string) time.Time {
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2/01/2006", "2/1/2006", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
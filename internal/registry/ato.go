@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// ParseTaxTransparency reads one year of the ATO Corporate Tax Transparency
+// report (Name, ABN, Total income $, Taxable income $, Tax payable $). The
+// files don't carry the income year, so the caller supplies it.
+func ParseTaxTransparency(r io.Reader, incomeYear int) ([]model.TaxRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	cols := columnIndex(header)
+	for _, required := range []string{"abn", "total_income"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("tax transparency data missing %q column", required)
+		}
+	}
+
+	var records []model.TaxRecord
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := getter(cols, record)
+
+		abn := NormalizeABN(get("abn"))
+		if abn == "" {
+			continue
+		}
+		records = append(records, model.TaxRecord{
+			ABN:           abn,
+			IncomeYear:    incomeYear,
+			Name:          get("name"),
+			TotalIncome:   parseDollars(get("total_income")),
+			TaxableIncome: parseDollars(get("taxable_income")),
+			TaxPayable:    parseDollars(get("tax_payable")),
+		})
+	}
+	return records, nil
+}
+
+// parseDollars reads "1,234,567" or "$1234567"; blanks and junk are nil.
+func parseDollars(raw string) *int64 {
+	raw = strings.NewReplacer(",", "", "$", "", " ", "").Replace(raw)
+	if raw == "" {
+		return nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
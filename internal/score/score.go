@@ -0,0 +1,254 @@
+package score
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// weights.json is the default config; -score-config replaces it.
+//
+//go:embed weights.json
+var defaultWeights []byte
+
+// AgeRule scores years linearly from 0 at MinYears to 1 at FullYears.
+type AgeRule struct {
+	Weight    float64 `json:"weight"`
+	MinYears  float64 `json:"min_years"`
+	FullYears float64 `json:"full_years"`
+}
+
+// SourcesRule scores corroboration: 0 for a single source, 1 at FullAt.
+type SourcesRule struct {
+	Weight float64 `json:"weight"`
+	FullAt int     `json:"full_at"`
+}
+
+// ContactRule scores the share of phone, email and website we hold.
+type ContactRule struct {
+	Weight float64 `json:"weight"`
+}
+
+// LocationRule scores by SA4 region, falling back to state, then Default.
+type LocationRule struct {
+	Weight  float64            `json:"weight"`
+	Default float64            `json:"default"`
+	States  map[string]float64 `json:"states"`
+	Regions map[string]float64 `json:"regions"`
+}
+
+// EntityTypeRule scores by ABR entity type code.
+type EntityTypeRule struct {
+	Weight  float64            `json:"weight"`
+	Default float64            `json:"default"`
+	Codes   map[string]float64 `json:"codes"`
+}
+
+// WebsiteRule adds up the values of the signals a lead shows, capped at 1.
+// A neglected web presence suggests an owner who isn't investing for the long
+// run, which is what we're looking for.
+type WebsiteRule struct {
+	Weight      float64 `json:"weight"`
+	StaleBefore int     `json:"stale_before"` // Copyright year before this is stale
+	Stale       float64 `json:"stale"`
+	NoHTTPS     float64 `json:"no_https"`
+	NoWebsite   float64 `json:"no_website"`
+}
+
+type Config struct {
+	BusinessAge AgeRule        `json:"business_age"`
+	GSTAge      AgeRule        `json:"gst_age"`
+	Sources     SourcesRule    `json:"sources"`
+	Contact     ContactRule    `json:"contact"`
+	Location    LocationRule   `json:"location"`
+	EntityType  EntityTypeRule `json:"entity_type"`
+	Website     WebsiteRule    `json:"website"`
+}
+
+func Default() Config {
+	cfg, err := Parse(defaultWeights)
+	if err != nil {
+		panic(fmt.Sprintf("score: embedded weights are invalid: %v", err))
+	}
+	return cfg
+}
+
+func LoadFile(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("could not read score config: %w", err)
+	}
+	return Parse(data)
+}
+
+func Parse(data []byte) (Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid score config: %w", err)
+	}
+	if cfg.totalWeight() <= 0 {
+		return Config{}, fmt.Errorf("invalid score config: all weights are zero")
+	}
+	for _, r := range []AgeRule{cfg.BusinessAge, cfg.GSTAge} {
+		if r.Weight > 0 && r.FullYears <= r.MinYears {
+			return Config{}, fmt.Errorf("invalid score config: full_years must be greater than min_years")
+		}
+	}
+	return cfg, nil
+}
+
+func (c Config) totalWeight() float64 {
+	return c.BusinessAge.Weight + c.GSTAge.Weight + c.Sources.Weight + c.Contact.Weight +
+		c.Location.Weight + c.EntityType.Weight + c.Website.Weight
+}
+
+// Hash identifies the weights, so stored scores can be recomputed when they
+// change. Reformatting the file doesn't change it.
+func (c Config) Hash() string {
+	canonical, _ := json.Marshal(c)
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+type Scorer struct {
+	cfg  Config
+	hash string
+	now  func() time.Time
+}
+
+func NewScorer(cfg Config) *Scorer {
+	return &Scorer{cfg: cfg, hash: cfg.Hash(), now: time.Now}
+}
+
+func (s *Scorer) Hash() string {
+	return s.hash
+}
+
+// Score rates a lead from 0 to 100. The breakdown holds each rule's points,
+// summing to the total.
+func (s *Scorer) Score(l *model.Lead) model.Score {
+	factors := []struct {
+		rule   string
+		weight float64
+		factor float64
+	}{
+		{"business_age", s.cfg.BusinessAge.Weight, s.ageFactor(s.cfg.BusinessAge, l.RegistrationDate)},
+		{"gst_age", s.cfg.GSTAge.Weight, s.ageFactor(s.cfg.GSTAge, l.GSTEffectiveFrom)},
+		{"sources", s.cfg.Sources.Weight, sourcesFactor(s.cfg.Sources, l.Sources)},
+		{"contact", s.cfg.Contact.Weight, contactFactor(l)},
+		{"location", s.cfg.Location.Weight, locationFactor(s.cfg.Location, l)},
+		{"entity_type", s.cfg.EntityType.Weight, entityTypeFactor(s.cfg.EntityType, l)},
+		{"website", s.cfg.Website.Weight, websiteFactor(s.cfg.Website, l)},
+	}
+
+	total := s.cfg.totalWeight()
+	out := model.Score{Breakdown: make(map[string]float64), Weights: s.hash}
+	for _, f := range factors {
+		if f.weight == 0 {
+			continue
+		}
+		points := round(100 * f.weight * clamp(f.factor) / total)
+		out.Breakdown[f.rule] = points
+		out.Total += points
+	}
+	out.Total = round(out.Total)
+	return out
+}
+
+func (s *Scorer) ageFactor(r AgeRule, since time.Time) float64 {
+	if r.Weight == 0 || since.Year() <= 1 {
+		return 0
+	}
+	years := s.now().Sub(since).Hours() / (24 * 365.25)
+	return (years - r.MinYears) / (r.FullYears - r.MinYears)
+}
+
+func sourcesFactor(r SourcesRule, sources []string) float64 {
+	distinct := make(map[string]bool)
+	for _, src := range sources {
+		if src = strings.TrimSpace(src); src != "" {
+			distinct[strings.ToUpper(src)] = true
+		}
+	}
+	if len(distinct) < 2 {
+		return 0
+	}
+	if r.FullAt <= 2 {
+		return 1
+	}
+	return float64(len(distinct)-1) / float64(r.FullAt-1)
+}
+
+func contactFactor(l *model.Lead) float64 {
+	have := 0
+	for _, v := range []string{l.Phone, l.Email, l.BusinessURL} {
+		if strings.TrimSpace(v) != "" {
+			have++
+		}
+	}
+	return float64(have) / 3
+}
+
+func locationFactor(r LocationRule, l *model.Lead) float64 {
+	for region, v := range r.Regions {
+		if l.Region != "" && strings.EqualFold(region, l.Region) {
+			return v
+		}
+	}
+	for state, v := range r.States {
+		if l.State != "" && strings.EqualFold(state, l.State) {
+			return v
+		}
+	}
+	return r.Default
+}
+
+func entityTypeFactor(r EntityTypeRule, l *model.Lead) float64 {
+	code := l.EntityTypeCode
+	if code == "" {
+		code = model.EntityTypeCodeFor(l.EntityType)
+	}
+	for c, v := range r.Codes {
+		if strings.EqualFold(c, string(code)) {
+			return v
+		}
+	}
+	return r.Default
+}
+
+func websiteFactor(r WebsiteRule, l *model.Lead) float64 {
+	if l.BusinessURL == "" {
+		return r.NoWebsite
+	}
+	w := l.Website
+	if w.CheckedAt.IsZero() {
+		return 0
+	}
+	var f float64
+	if r.StaleBefore > 0 && w.CopyrightYear > 0 && w.CopyrightYear < r.StaleBefore {
+		f += r.Stale
+	}
+	if !w.HTTPS {
+		f += r.NoHTTPS
+	}
+	return f
+}
+
+func clamp(f float64) float64 {
+	return math.Max(0, math.Min(1, f))
+}
+
+func round(f float64) float64 {
+	return math.Round(f*10) / 10
+}
@@ -44,6 +44,9 @@ func (s *ABRSearchSource) Fetch(ctx context.Context) ([]model.Lead, error) {
 			s.logger.Error("ABR search failed", "kw", kw, "err", err)
 			continue
 		}
+		for i := range leads {
+			leads[i].SearchKeyword = kw
+		}
 		allLeads = append(allLeads, leads...)
 	}
 	return allLeads, nil
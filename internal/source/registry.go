@@ -0,0 +1,78 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NorthLinkDirectory is one of the NorthLink member directories we scrape.
+// Each is stored as its own source.
+type NorthLinkDirectory struct {
+	URL      string
+	Category string
+	Name     string
+}
+
+var NorthLinkDirectories = []NorthLinkDirectory{
+	{"https://northlink.org.au/melbournes-north-food-group/manufacturer-directory/", "Manufacturing", "NorthLink-FoodMfg"},
+	{"https://northlink.org.au/melbournes-north-food-group/service-provider-directory/", "Service Provider", "NorthLink-FoodSvc"},
+	{"https://northlink.org.au/melbournes-north-advanced-manufacturing-group/partner-directory/", "Manufacturing", "NorthLink-MfgPartner"},
+}
+
+// sourceNames maps the -sources keys onto the names their leads are stored
+// under, i.e. each Sourcer's Name().
+var sourceNames = map[string][]string{
+	"rto":        {"RTO"},
+	"amtil":      {"AMTIL"},
+	"semma":      {"SEMMA"},
+	"hobsonsbay": {"HobsonsBay"},
+	"abr":        {"ABR-Search"},
+	"austmfg":    {"AustMfg"},
+	"iba":        {"IBA"},
+	"csv":        {"CSV"},
+}
+
+func init() {
+	for _, d := range NorthLinkDirectories {
+		sourceNames["northlink"] = append(sourceNames["northlink"], d.Name)
+	}
+}
+
+// ResolveNames maps -sources keys (northlink) and exact source names
+// (NorthLink-FoodMfg), case-insensitively, onto stored source names.
+func ResolveNames(keys []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if mapped, ok := sourceNames[strings.ToLower(key)]; ok {
+			for _, name := range mapped {
+				add(name)
+			}
+			continue
+		}
+		found := false
+		for _, mapped := range sourceNames {
+			for _, name := range mapped {
+				if strings.EqualFold(name, key) {
+					add(name)
+					found = true
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown source %q", key)
+		}
+	}
+	return names, nil
+}
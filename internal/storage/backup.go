@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BackupManifest describes a backup, and is written alongside it.
+type BackupManifest struct {
+	Backend       string    `json:"backend"`
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int       `json:"schema_version"`
+	Leads         int       `json:"leads"`
+}
+
+// Backup describes one backup directory.
+type Backup struct {
+	Dir string
+	BackupManifest
+}
+
+const manifestFile = "backup.json"
+
+// backupTimeFormat names backup directories, so they sort oldest first.
+const backupTimeFormat = "20060102T150405"
+
+// sqliteBackupFile is the database copy a SQLite backup holds.
+const sqliteBackupFile = "sourcing.sqlite"
+
+// NewBackupDir returns a new backup directory's path under root, named for
+// when it was taken.
+func NewBackupDir(root string, at time.Time) string {
+	return filepath.Join(root, at.UTC().Format(backupTimeFormat))
+}
+
+// Backup writes a full copy of the database to dir, which must not exist
+// yet: a compacted copy of the SQLite file.
+func (r *SQLiteRepo) Backup(ctx context.Context, dir string) error {
+	if err := newBackupDir(dir); err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, "VACUUM INTO ?", filepath.Join(dir, sqliteBackupFile)); err != nil {
+		return err
+	}
+	return r.writeManifest(ctx, dir)
+}
+
+// Restore loads a backup into the database, which must be empty: a new
+// file, not yet initialised. Tables are created from the backup's own
+// schema, so keys and defaults come back too.
+func (r *SQLiteRepo) Restore(ctx context.Context, dir string) error {
+	if err := r.checkRestore(ctx, dir, "SELECT count(*) FROM sqlite_master WHERE name NOT LIKE 'sqlite_%'"); err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, "ATTACH DATABASE ? AS backup", filepath.Join(dir, sqliteBackupFile)); err != nil {
+		return err
+	}
+	defer r.db.ExecContext(context.Background(), "DETACH DATABASE backup")
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT type, name, sql FROM backup.sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+		ORDER BY CASE type WHEN 'table' THEN 0 WHEN 'index' THEN 1 ELSE 2 END, rowid`)
+	if err != nil {
+		return err
+	}
+	type object struct{ kind, name, sql string }
+	var objects []object
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.kind, &o.name, &o.sql); err != nil {
+			rows.Close()
+			return err
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, o := range objects {
+		if _, err := tx.ExecContext(ctx, o.sql); err != nil {
+			return fmt.Errorf("create %s %s: %w", o.kind, o.name, err)
+		}
+		if o.kind == "table" {
+			name := `"` + strings.ReplaceAll(o.name, `"`, `""`) + `"`
+			if _, err := tx.ExecContext(ctx, "INSERT INTO main."+name+" SELECT * FROM backup."+name); err != nil {
+				return fmt.Errorf("copy table %s: %w", o.name, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func newBackupDir(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("backup directory %s already exists", dir)
+	}
+	return os.MkdirAll(dir, 0o755)
+}
+
+func (r *sqlRepo) writeManifest(ctx context.Context, dir string) error {
+	m := BackupManifest{Backend: r.dialect.name, CreatedAt: time.Now().UTC()}
+	var err error
+	if m.SchemaVersion, err = r.SchemaVersion(ctx); err != nil {
+		return err
+	}
+	if err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM leads").Scan(&m.Leads); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, manifestFile), append(data, '\n'), 0o644)
+}
+
+// checkRestore refuses a restore from anything but a backup of this backend,
+// or into a database that already has tables, which countTables counts.
+func (r *sqlRepo) checkRestore(ctx context.Context, dir, countTables string) error {
+	m, err := ReadBackupManifest(dir)
+	if err != nil {
+		return err
+	}
+	if m.Backend != r.dialect.name {
+		return fmt.Errorf("%s is a %s backup, not %s", dir, m.Backend, r.dialect.name)
+	}
+	var tables int
+	if err := r.db.QueryRowContext(ctx, countTables).Scan(&tables); err != nil {
+		return err
+	}
+	if tables > 0 {
+		return fmt.Errorf("the database isn't empty; restore into a new file")
+	}
+	return nil
+}
+
+// ReadBackupManifest reads the manifest of the backup in dir.
+func ReadBackupManifest(dir string) (BackupManifest, error) {
+	var m BackupManifest
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return m, fmt.Errorf("%s is not a backup: %w", dir, err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("bad manifest in %s: %w", dir, err)
+	}
+	return m, nil
+}
+
+// dbFileSuffixes are the files that go with a database file: DuckDB's and
+// SQLite's write-ahead logs, and SQLite's shared memory.
+var dbFileSuffixes = []string{"", ".wal", "-wal", "-shm"}
+
+// RestoreFile restores the backup in dir to the database dsn names, and
+// brings it up to date. The backup is restored into a new file beside it,
+// which replaces the database only once it's complete; an existing database
+// is kept as <path>.pre-restore-<time>, returned as aside. If anything
+// fails, the database is left as it was.
+func RestoreFile(ctx context.Context, dsn, dir string, logger *slog.Logger) (aside string, err error) {
+	backend, path, err := ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	stamp := time.Now().Format(backupTimeFormat)
+	restoring := path + ".restoring-" + stamp
+	if err := restoreInto(ctx, backend+"://"+restoring, dir, logger); err != nil {
+		removeDBFiles(restoring)
+		return "", err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		aside = path + ".pre-restore-" + stamp
+		if err := moveDBFiles(path, aside); err != nil {
+			moveDBFiles(aside, path)
+			removeDBFiles(restoring)
+			return "", fmt.Errorf("move the database aside: %w", err)
+		}
+	}
+	if err := moveDBFiles(restoring, path); err != nil {
+		if aside != "" {
+			removeDBFiles(path)
+			moveDBFiles(aside, path)
+		}
+		removeDBFiles(restoring)
+		return "", fmt.Errorf("move the restored database into place: %w", err)
+	}
+	return aside, nil
+}
+
+// restoreInto restores the backup in dir to the new database dsn names,
+// closing it once it's up to date.
+func restoreInto(ctx context.Context, dsn, dir string, logger *slog.Logger) error {
+	repo, err := Open(dsn, logger)
+	if err != nil {
+		return err
+	}
+	if err := repo.Restore(ctx, dir); err != nil {
+		repo.Close()
+		return err
+	}
+	// Bring a backup from an older schema up to date
+	if err := repo.Init(ctx); err != nil {
+		repo.Close()
+		return err
+	}
+	return repo.Close()
+}
+
+// moveDBFiles renames the database file from, and the files that go with
+// it, to to.
+func moveDBFiles(from, to string) error {
+	for _, suffix := range dbFileSuffixes {
+		if _, err := os.Stat(from + suffix); err != nil {
+			continue
+		}
+		if err := os.Rename(from+suffix, to+suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeDBFiles removes the database file path and the files that go with
+// it, if they're there.
+func removeDBFiles(path string) {
+	for _, suffix := range dbFileSuffixes {
+		os.Remove(path + suffix)
+	}
+}
+
+// ListBackups returns the backups under root, newest first.
+func ListBackups(root string) ([]Backup, error) {
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var backups []Backup
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		m, err := ReadBackupManifest(dir)
+		if err != nil {
+			// Not one of ours, or unfinished; leave it alone
+			continue
+		}
+		backups = append(backups, Backup{Dir: dir, BackupManifest: m})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// PruneBackups removes the backups under root the retention policy no
+// longer covers: those beyond the newest keep, and those older than maxAge.
+// Zero for either doesn't limit it, and the newest backup is always kept.
+// It returns the removed directories.
+func PruneBackups(root string, keep int, maxAge time.Duration, now time.Time) ([]string, error) {
+	backups, err := ListBackups(root)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for i, b := range backups {
+		if i == 0 {
+			continue
+		}
+		if (keep > 0 && i >= keep) || (maxAge > 0 && now.Sub(b.CreatedAt) > maxAge) {
+			if err := os.RemoveAll(b.Dir); err != nil {
+				return removed, err
+			}
+			removed = append(removed, b.Dir)
+		}
+	}
+	return removed, nil
+}
+
+// quoteLiteral quotes s as a SQL string literal, for the few places a
+// placeholder isn't allowed, like EXPORT DATABASE's directory.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
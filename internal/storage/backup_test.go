@@ -0,0 +1,97 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+// testRestoreFile restores over an existing database, first from a broken
+// backup, which must leave it as it was, then from a good one. prefix opens
+// a path as a repository.
+func testRestoreFile(t *testing.T, prefix string) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+	path := filepath.Join(dir, "leads.db")
+	backup := filepath.Join(dir, "backup")
+
+	repo, err := storage.Open(prefix+path, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Init(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.SaveLead(ctx, model.Lead{ABN: "11000000001", Name: "Acme Engineering Pty Ltd", State: "VIC"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Backup(ctx, backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same backup with everything but its manifest garbled
+	broken := filepath.Join(dir, "broken")
+	if err := os.CopyFS(broken, os.DirFS(backup)); err != nil {
+		t.Fatal(err)
+	}
+	files, err := os.ReadDir(broken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		if f.Name() != "backup.json" {
+			if err := os.WriteFile(filepath.Join(broken, f.Name()), []byte("not a backup"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if _, err := storage.RestoreFile(ctx, prefix+path, broken, logger); err == nil {
+		t.Fatal("restored a broken backup")
+	}
+	if left, _ := filepath.Glob(path + ".*"); len(left) != 0 {
+		t.Errorf("a failed restore left %v", left)
+	}
+	countLeads(t, prefix+path, 1)
+
+	aside, err := storage.RestoreFile(ctx, prefix+path, backup, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aside == "" {
+		t.Fatal("the existing database wasn't kept aside")
+	}
+	countLeads(t, prefix+aside, 1)
+	countLeads(t, prefix+path, 1)
+}
+
+// countLeads checks the database dsn names has want leads.
+func countLeads(t *testing.T, dsn string, want int) {
+	t.Helper()
+	repo, err := storage.Open(dsn, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	n, err := repo.CountLeads(context.Background(), storage.LeadQuery{})
+	if err != nil {
+		t.Fatalf("%s: %v", dsn, err)
+	}
+	if n != want {
+		t.Errorf("%s has %d leads, want %d", dsn, n, want)
+	}
+}
+
+func TestRestoreFileSQLite(t *testing.T) {
+	testRestoreFile(t, "sqlite:")
+}
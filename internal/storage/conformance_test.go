@@ -0,0 +1,24 @@
+package storage_test
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/shanehull/sourcerer/internal/storage"
+	"github.com/shanehull/sourcerer/internal/storage/storagetest"
+)
+
+// runConformance runs every storagetest check against the backend with the
+// given -db prefix, each as its own subtest.
+func runConformance(t *testing.T, prefix string) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	storagetest.Run(t, func(dir string) (storage.Repository, error) {
+		return storage.Open(prefix+filepath.Join(dir, "leads.db"), logger)
+	})
+}
+
+func TestConformanceSQLite(t *testing.T) {
+	runConformance(t, "sqlite:")
+}
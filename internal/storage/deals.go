@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// SetDeal replaces a lead's deal tracking with d. It's kept apart from
+// SaveLead, which never touches these columns, so the pipeline can't undo
+// what the team entered.
+func (r *sqlRepo) SetDeal(ctx context.Context, abn string, d model.Deal) error {
+	if d.Status == "" {
+		d.Status = model.DealNew
+	}
+	if d.UpdatedAt.IsZero() {
+		d.UpdatedAt = time.Now()
+	}
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE leads SET deal_status = ?, deal_owner = ?, next_action = ?, next_action_at = ?, deal_updated_at = ?
+		WHERE abn = ?`,
+		string(d.Status), nullString(d.Owner), nullString(d.NextAction), nullTime(d.NextActionAt), d.UpdatedAt, abn)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("no lead with ABN %s", abn)
+	}
+	return nil
+}
+
+// AddLeadNote stores a note on a lead and returns its ID.
+func (r *sqlRepo) AddLeadNote(ctx context.Context, n model.LeadNote) (string, error) {
+	if n.ABN == "" || n.Note == "" {
+		return "", fmt.Errorf("a note needs an ABN and some text")
+	}
+	if n.ID == "" {
+		b := make([]byte, 6)
+		_, _ = rand.Read(b)
+		n.ID = hex.EncodeToString(b)
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	_, err := r.db.ExecContext(ctx, "INSERT INTO lead_notes (id, abn, note, author, created_at) VALUES (?, ?, ?, ?, ?)",
+		n.ID, n.ABN, n.Note, n.Author, n.CreatedAt)
+	return n.ID, err
+}
+
+// ListLeadNotes returns a lead's notes, oldest first.
+func (r *sqlRepo) ListLeadNotes(ctx context.Context, abn string) ([]model.LeadNote, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, abn, coalesce(note, ''), coalesce(author, ''), created_at
+		FROM lead_notes WHERE abn = ? ORDER BY created_at, id`, abn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var notes []model.LeadNote
+	for rows.Next() {
+		var n model.LeadNote
+		if err := rows.Scan(&n.ID, &n.ABN, &n.Note, &n.Author, &n.CreatedAt); err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
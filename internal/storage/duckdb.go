@@ -1,230 +1,58 @@
+//go:build cgo
+
 package storage
 
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"log/slog"
-	"strings"
-	"time"
 
 	_ "github.com/marcboeker/go-duckdb"
-	"github.com/shanehull/sourcerer/internal/model"
 )
 
+// DuckDBRepo stores leads in a DuckDB file. go-duckdb needs cgo, so builds
+// without it get the stub in duckdb_nocgo.go instead.
 type DuckDBRepo struct {
-	db     *sql.DB
-	logger *slog.Logger
+	*sqlRepo
 }
 
+var _ Repository = (*DuckDBRepo)(nil)
+
 func NewDuckDBRepo(path string, logger *slog.Logger) (*DuckDBRepo, error) {
 	db, err := sql.Open("duckdb", path)
 	if err != nil {
 		return nil, err
 	}
-	return &DuckDBRepo{db: db, logger: logger}, nil
+	return &DuckDBRepo{&sqlRepo{db: db, dialect: dialect{name: "duckdb"}, logger: logger}}, nil
 }
 
-func (r *DuckDBRepo) Init(ctx context.Context) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS leads (
-		abn TEXT PRIMARY KEY,
-		name TEXT,
-		category TEXT,
-		sources TEXT,
-		entity_type TEXT,
-		entity_status TEXT,
-		state TEXT,
-		postcode TEXT,
-		registration_date TIMESTAMP,
-		age_years INTEGER,
-		gst_registered BOOLEAN,
-		gst_effective_from TIMESTAMP,
-		is_current_entity BOOLEAN,
-		acn TEXT,
-		main_trading_name TEXT,
-		phone TEXT,
-		email TEXT,
-		business_url TEXT,
-		found_at_url TEXT,
-		updated_at TIMESTAMP
-	);`
-	_, err := r.db.ExecContext(ctx, query)
-	return err
-}
-
-// GetLeadByName checks if we already have an enriched lead by its name
-func (r *DuckDBRepo) GetLeadByName(ctx context.Context, name string) (*model.Lead, error) {
-	query := `SELECT abn, name, category, entity_type, state, registration_date, gst_registered 
-	          FROM leads WHERE lower(name) = ? LIMIT 1`
-	row := r.db.QueryRowContext(ctx, query, strings.ToLower(name))
-
-	var l model.Lead
-	err := row.Scan(&l.ABN, &l.Name, &l.Category, &l.EntityType, &l.State, &l.RegistrationDate, &l.IsGSTRegistered)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
+// openDuckDB is Open's DuckDB backend.
+func openDuckDB(path string, logger *slog.Logger) (Repository, error) {
+	r, err := NewDuckDBRepo(path, logger)
 	if err != nil {
 		return nil, err
 	}
-	return &l, nil
+	return r, nil
 }
 
-func (r *DuckDBRepo) SaveLead(ctx context.Context, l model.Lead) (bool, error) {
-	var exists bool
-	_ = r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM leads WHERE abn = ?)", l.ABN).Scan(&exists)
-
-	sourceStr := strings.Join(l.Sources, ",")
-	ageYears := l.AgeYears()
-	
-	query := `
-	INSERT INTO leads (abn, name, category, sources, entity_type, entity_status, state, postcode, registration_date, age_years, gst_registered, gst_effective_from, is_current_entity, acn, main_trading_name, phone, email, business_url, found_at_url, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	ON CONFLICT (abn) DO UPDATE SET
-		sources = CASE WHEN CONTAINS(leads.sources, EXCLUDED.sources) THEN leads.sources ELSE leads.sources || ',' || EXCLUDED.sources END,
-		age_years = EXCLUDED.age_years,
-		gst_registered = EXCLUDED.gst_registered,
-		gst_effective_from = EXCLUDED.gst_effective_from,
-		is_current_entity = EXCLUDED.is_current_entity,
-		acn = EXCLUDED.acn,
-		main_trading_name = EXCLUDED.main_trading_name,
-		phone = EXCLUDED.phone,
-		email = EXCLUDED.email,
-		business_url = EXCLUDED.business_url,
-		updated_at = EXCLUDED.updated_at;`
-
-	_, err := r.db.ExecContext(ctx, query, l.ABN, l.Name, l.Category, sourceStr, l.EntityType, l.EntityStatus, l.State, l.Postcode, l.RegistrationDate, ageYears, l.IsGSTRegistered, l.GSTEffectiveFrom, l.IsCurrentEntity, l.ACN, l.MainTradingName, l.Phone, l.Email, l.BusinessURL, l.FoundAtURL, time.Now())
-	return !exists, err
-}
-
-func (r *DuckDBRepo) ExportCSV(ctx context.Context, path string, minAge int, states []string, sources []string) error {
-	cutoff := time.Now().AddDate(-minAge, 0, 0).Format("2006-01-02")
-	filters := []string{
-		fmt.Sprintf("registration_date <= '%s'", cutoff),
-		"gst_registered = TRUE",
-		"lower(entity_type) NOT LIKE '%public company%'",
-		"lower(entity_type) NOT LIKE '%government%'",
-	}
-
-	if len(states) > 0 && states[0] != "" {
-		filters = append(filters, fmt.Sprintf("state IN ('%s')", strings.Join(states, "','")))
-	}
-
-	if len(sources) > 0 && sources[0] != "" {
-		var srcConds []string
-		for _, s := range sources {
-			srcConds = append(srcConds, fmt.Sprintf("contains(upper(sources), '%s')", strings.ToUpper(s)))
-		}
-		filters = append(filters, "("+strings.Join(srcConds, " OR ")+")")
-	}
-
-	query := fmt.Sprintf(`
-		COPY (
-			SELECT abn, name, category, entity_type, entity_status, sources, state, postcode, registration_date, age_years, gst_registered, gst_effective_from, is_current_entity, acn, main_trading_name, phone, email, business_url, found_at_url, updated_at 
-			FROM leads 
-			WHERE %s 
-			ORDER BY registration_date ASC
-		) TO '%s' (HEADER, DELIMITER ',');`, strings.Join(filters, " AND "), path)
-
-	_, err := r.db.ExecContext(ctx, query)
-	return err
-}
-
-func (r *DuckDBRepo) DeleteLeadByName(ctx context.Context, name string) error {
-	query := `DELETE FROM leads WHERE lower(name) = ?`
-	_, err := r.db.ExecContext(ctx, query, strings.ToLower(name))
-	return err
-}
-
-func (r *DuckDBRepo) DeleteLeadByFilters(ctx context.Context, filters map[string]interface{}) (int64, error) {
-	var conditions []string
-	var args []interface{}
-
-	if name, ok := filters["name"].(string); ok {
-		conditions = append(conditions, "lower(name) = ?")
-		args = append(args, strings.ToLower(name))
+// Backup writes a full copy of the database to dir, which must not exist
+// yet: DuckDB's EXPORT DATABASE, as Parquet.
+func (r *DuckDBRepo) Backup(ctx context.Context, dir string) error {
+	if err := newBackupDir(dir); err != nil {
+		return err
 	}
-
-	if abn, ok := filters["abn"].(string); ok {
-		conditions = append(conditions, "abn = ?")
-		args = append(args, abn)
-	}
-
-	if age, ok := filters["age"].(int); ok && age > 0 {
-		cutoff := time.Now().AddDate(-age, 0, 0)
-		nextYear := time.Now().AddDate(-age+1, 0, 0)
-		conditions = append(conditions, "registration_date >= ? AND registration_date < ?")
-		args = append(args, cutoff, nextYear)
-	}
-
-	if source, ok := filters["source"].(string); ok && source != "" {
-		conditions = append(conditions, fmt.Sprintf("contains(upper(sources), '%s')", strings.ToUpper(source)))
-	}
-
-	if state, ok := filters["state"].(string); ok && state != "" {
-		conditions = append(conditions, "upper(state) = ?")
-		args = append(args, strings.ToUpper(state))
-	}
-
-	if entityType, ok := filters["entity_type"].(string); ok && entityType != "" {
-		conditions = append(conditions, "lower(entity_type) LIKE ?")
-		args = append(args, "%"+strings.ToLower(entityType)+"%")
-	}
-
-	if notGst, ok := filters["not_gst"].(bool); ok && notGst {
-		conditions = append(conditions, "gst_registered = false")
-	}
-
-	if notPriv, ok := filters["not_private"].(bool); ok && notPriv {
-		// Delete if entity_type contains: public company, government, sole trader, individual, other incorporated entity, trust
-		conditions = append(conditions, `(
-			lower(entity_type) LIKE '%public company%' OR
-			lower(entity_type) LIKE '%government%' OR
-			lower(entity_type) LIKE '%sole trader%' OR
-			lower(entity_type) LIKE '%individual%' OR
-			lower(entity_type) LIKE '%other incorporated entity%' OR
-			lower(entity_type) LIKE '%trust%'
-		)`)
+	if _, err := r.db.ExecContext(ctx, "EXPORT DATABASE "+quoteLiteral(dir)+" (FORMAT PARQUET)"); err != nil {
+		return err
 	}
-
-	if len(conditions) == 0 {
-		return 0, fmt.Errorf("no filters provided")
-	}
-
-	query := fmt.Sprintf("DELETE FROM leads WHERE %s", strings.Join(conditions, " AND "))
-	result, err := r.db.ExecContext(ctx, query, args...)
-	if err != nil {
-		return 0, err
-	}
-	
-	rowsAffected, err := result.RowsAffected()
-	return rowsAffected, err
-}
-
-func (r *DuckDBRepo) Close() error {
-	return r.db.Close()
-}
-
-func (r *DuckDBRepo) GetDB() *sql.DB {
-	return r.db
+	return r.writeManifest(ctx, dir)
 }
 
-func (r *DuckDBRepo) CountQualified(ctx context.Context, minAge int, allowedStates []string) (int, error) {
-	var stateFilter string
-	if len(allowedStates) > 0 {
-		stateList := "'" + strings.Join(allowedStates, "','") + "'"
-		stateFilter = fmt.Sprintf("AND upper(state) IN (%s)", strings.ToUpper(stateList))
+// Restore loads a backup into the database, which must be empty: a new
+// file, not yet initialised.
+func (r *DuckDBRepo) Restore(ctx context.Context, dir string) error {
+	if err := r.checkRestore(ctx, dir, "SELECT count(*) FROM information_schema.tables WHERE table_schema = 'main'"); err != nil {
+		return err
 	}
-
-	// Just count all leads that pass basic filters
-	query := fmt.Sprintf(`
-		SELECT COUNT(*) FROM leads
-		WHERE gst_registered = true
-		AND is_current_entity = true
-		%s
-	`, stateFilter)
-
-	var count int
-	err := r.db.QueryRowContext(ctx, query).Scan(&count)
-	return count, err
+	_, err := r.db.ExecContext(ctx, "IMPORT DATABASE "+quoteLiteral(dir))
+	return err
 }
@@ -0,0 +1,14 @@
+//go:build !cgo
+
+package storage
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// openDuckDB is Open's DuckDB backend, which go-duckdb can't provide without
+// cgo. SQLite needs no cgo, so cgo-free builds use that.
+func openDuckDB(string, *slog.Logger) (Repository, error) {
+	return nil, errors.New("DuckDB support not compiled in (built without cgo); use sqlite:path")
+}
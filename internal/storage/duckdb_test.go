@@ -0,0 +1,17 @@
+//go:build cgo
+
+package storage_test
+
+import "testing"
+
+func TestConformanceDuckDB(t *testing.T) {
+	runConformance(t, "duckdb://")
+}
+
+func TestRadiusAfterMigrationDuckDB(t *testing.T) {
+	testRadiusAfterMigration(t, "duckdb", "duckdb://")
+}
+
+func TestRestoreFileDuckDB(t *testing.T) {
+	testRestoreFile(t, "duckdb://")
+}
@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// maxSharedContact is the most leads a domain or phone number can be shared
+// by and still suggest they're duplicates. More than that is an accountant,
+// a franchise head office or a web agency's site, not one business.
+const maxSharedContact = 5
+
+// mergedTables are the tables keyed by ABN whose rows a merge moves onto the
+// surviving lead. Notes and outcomes, which have keys of their own, are
+// simply repointed. Field values are about the merged lead's own record, so
+// they're dropped with it; its export hand-offs stay, so deltas report it
+// dropped.
+var mergedTables = []string{"lead_sources", "lead_names", "lead_tags", "lead_list_members"}
+
+// FindDuplicates returns pairs of leads that look like the same business:
+// the same normalized name under different ABNs, one's trading name matching
+// the other's name, both trading under the same name, or a shared website
+// domain or phone number. Pairs that have been rejected are left out. Pairs
+// are sorted by ABN.
+func (r *sqlRepo) FindDuplicates(ctx context.Context) ([]model.DuplicatePair, error) {
+	rejections, err := r.ListDuplicateRejections(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rejected := make(map[[2]string]bool, len(rejections))
+	for _, rj := range rejections {
+		rejected[[2]string{rj.A, rj.B}] = true
+	}
+
+	reasons := make(map[[2]string][]model.DuplicateReason)
+	add := func(a, b string, reason model.DuplicateReason) {
+		a, b = model.OrderedPair(a, b)
+		key := [2]string{a, b}
+		if a == b || rejected[key] || slices.Contains(reasons[key], reason) {
+			return
+		}
+		reasons[key] = append(reasons[key], reason)
+	}
+
+	// Listing names are left out: sources list a business under all sorts of
+	// names, and LookupLead already settles which ABN they mean
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.abn, b.abn, a.kind, b.kind
+		FROM lead_names a JOIN lead_names b ON b.name_key = a.name_key AND b.abn > a.abn
+		WHERE a.kind IN (?, ?) AND b.kind IN (?, ?)`,
+		nameKindName, nameKindTrading, nameKindName, nameKindTrading)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var a, b, kindA, kindB string
+		if err := rows.Scan(&a, &b, &kindA, &kindB); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		switch {
+		case kindA == nameKindName && kindB == nameKindName:
+			add(a, b, model.DuplicateName)
+		case kindA == nameKindTrading && kindB == nameKindTrading:
+			add(a, b, model.DuplicateSharedTradingName)
+		default:
+			add(a, b, model.DuplicateTradingName)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Domains and phone numbers are normalized in Go, which SQL can't do
+	// portably
+	rows, err = r.db.QueryContext(ctx, "SELECT abn, coalesce(business_url, ''), coalesce(phone, '') FROM leads ORDER BY abn")
+	if err != nil {
+		return nil, err
+	}
+	byDomain := make(map[string][]string)
+	byPhone := make(map[string][]string)
+	for rows.Next() {
+		var abn, url, phone string
+		if err := rows.Scan(&abn, &url, &phone); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if d := model.DuplicateDomainKey(url); d != "" {
+			byDomain[d] = append(byDomain[d], abn)
+		}
+		if p := model.NormalizePhone(phone); p != "" {
+			byPhone[p] = append(byPhone[p], abn)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for reason, groups := range map[model.DuplicateReason]map[string][]string{model.DuplicateDomain: byDomain, model.DuplicatePhone: byPhone} {
+		for _, abns := range groups {
+			if len(abns) > maxSharedContact {
+				continue
+			}
+			for i := range abns {
+				for j := i + 1; j < len(abns); j++ {
+					add(abns[i], abns[j], reason)
+				}
+			}
+		}
+	}
+
+	pairs := make([]model.DuplicatePair, 0, len(reasons))
+	for key, rs := range reasons {
+		slices.Sort(rs)
+		pairs = append(pairs, model.DuplicatePair{A: key[0], B: key[1], Reasons: rs})
+	}
+	slices.SortFunc(pairs, func(x, y model.DuplicatePair) int {
+		if c := strings.Compare(x.A, y.A); c != 0 {
+			return c
+		}
+		return strings.Compare(x.B, y.B)
+	})
+	return pairs, nil
+}
+
+// RejectDuplicate records that a and b aren't the same business, so
+// FindDuplicates stops offering them. Rejecting a pair twice is harmless.
+func (r *sqlRepo) RejectDuplicate(ctx context.Context, a, b, author string) error {
+	a, b = model.OrderedPair(strings.TrimSpace(a), strings.TrimSpace(b))
+	if a == "" || a == b {
+		return fmt.Errorf("a rejection needs two different ABNs")
+	}
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO duplicate_rejections (abn_a, abn_b, author, rejected_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (abn_a, abn_b) DO NOTHING`,
+		a, b, nullString(author), time.Now())
+	return err
+}
+
+// ListDuplicateRejections returns the rejected pairs, oldest first.
+func (r *sqlRepo) ListDuplicateRejections(ctx context.Context) ([]model.DuplicateRejection, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT abn_a, abn_b, coalesce(author, ''), rejected_at
+		FROM duplicate_rejections ORDER BY rejected_at, abn_a, abn_b`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var rejections []model.DuplicateRejection
+	for rows.Next() {
+		var rj model.DuplicateRejection
+		var at sql.NullTime
+		if err := rows.Scan(&rj.A, &rj.B, &rj.Author, &at); err != nil {
+			return nil, err
+		}
+		rj.RejectedAt = at.Time
+		rejections = append(rejections, rj)
+	}
+	return rejections, rows.Err()
+}
+
+// MergeLeads folds the merged leads into survivor: their sources, names,
+// notes, tags, list memberships and outcomes move onto it, and so does a
+// deal if survivor's hasn't been started. The merged leads are then deleted,
+// snapshotted in a delete batch as DeleteLeads does, and their ABNs
+// suppressed so the pipeline doesn't bring them back. Undoing the batch
+// restores the merged leads as they were and lifts their suppressions, but
+// leaves survivor with what it gained.
+func (r *sqlRepo) MergeLeads(ctx context.Context, survivor string, merged []string, author string) (model.DeleteBatch, error) {
+	now := time.Now()
+	batch := model.DeleteBatch{DeletedAt: now, Author: author, Filters: "merged into " + survivor}
+	var abns []string
+	for _, abn := range merged {
+		if abn = strings.TrimSpace(abn); abn != "" && !slices.Contains(abns, abn) {
+			abns = append(abns, abn)
+		}
+	}
+	if len(abns) == 0 {
+		return batch, fmt.Errorf("no leads to merge")
+	}
+	if slices.Contains(abns, survivor) {
+		return batch, fmt.Errorf("can't merge %s into itself", survivor)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return batch, err
+	}
+	defer tx.Rollback()
+
+	in, inArgs := inList(append([]string{survivor}, abns...))
+	found, err := queryStrings(ctx, tx, "SELECT abn FROM leads WHERE abn IN "+in, inArgs...)
+	if err != nil {
+		return batch, err
+	}
+	for _, abn := range append([]string{survivor}, abns...) {
+		if !slices.Contains(found, abn) {
+			return batch, fmt.Errorf("no lead with ABN %s", abn)
+		}
+	}
+
+	if err := newDeleteBatch(ctx, tx, &batch, abns); err != nil {
+		return batch, err
+	}
+
+	// A source that listed both keeps the earliest and latest sightings
+	in, inArgs = inList(abns)
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE lead_sources SET
+			first_seen = (SELECT min(s.first_seen) FROM lead_sources s WHERE s.source = lead_sources.source AND (s.abn = lead_sources.abn OR s.abn IN `+in+`)),
+			last_seen = (SELECT max(s.last_seen) FROM lead_sources s WHERE s.source = lead_sources.source AND (s.abn = lead_sources.abn OR s.abn IN `+in+`))
+		WHERE abn = ? AND source IN (SELECT source FROM lead_sources WHERE abn IN `+in+`)`,
+		append(append(append(slices.Clone(inArgs), inArgs...), survivor), inArgs...)...); err != nil {
+		return batch, fmt.Errorf("merge sources: %w", err)
+	}
+
+	for _, table := range []string{"lead_notes", "lead_outcomes"} {
+		if _, err := tx.ExecContext(ctx, "UPDATE "+table+" SET abn = ? WHERE abn IN "+in, append([]interface{}{survivor}, inArgs...)...); err != nil {
+			return batch, err
+		}
+	}
+	if err := mergeDeal(ctx, tx, survivor, abns); err != nil {
+		return batch, fmt.Errorf("merge deal: %w", err)
+	}
+
+	if err := deleteLeadRows(ctx, tx, abns); err != nil {
+		return batch, err
+	}
+
+	// The rest move over from the snapshot once the originals are gone,
+	// skipping rows survivor already has
+	types := make(map[string]map[string]string)
+	rows, err := tx.QueryContext(ctx, "SELECT source_table, row_data FROM deleted_leads WHERE batch_id = ?", batch.ID)
+	if err != nil {
+		return batch, err
+	}
+	type snapshot struct{ table, data string }
+	var moves []snapshot
+	for rows.Next() {
+		var s snapshot
+		if err := rows.Scan(&s.table, &s.data); err != nil {
+			rows.Close()
+			return batch, err
+		}
+		if slices.Contains(mergedTables, s.table) {
+			moves = append(moves, s)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return batch, err
+	}
+	for _, s := range moves {
+		dec := json.NewDecoder(strings.NewReader(s.data))
+		dec.UseNumber()
+		var row map[string]interface{}
+		if err := dec.Decode(&row); err != nil {
+			return batch, err
+		}
+		row["abn"] = survivor
+		data, err := json.Marshal(row)
+		if err != nil {
+			return batch, err
+		}
+		if types[s.table] == nil {
+			if types[s.table], err = columnTypes(ctx, tx, s.table); err != nil {
+				return batch, err
+			}
+		}
+		if err := restoreRow(ctx, tx, s.table, string(data), types[s.table]); err != nil {
+			return batch, fmt.Errorf("move %s row: %w", s.table, err)
+		}
+	}
+
+	if err := rebuildLeadSources(ctx, tx, survivor); err != nil {
+		return batch, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE leads SET updated_at = ? WHERE abn = ?", now, survivor); err != nil {
+		return batch, err
+	}
+	for _, abn := range abns {
+		if _, err := addSuppression(ctx, tx, model.Suppression{ABN: abn, Reason: "merged into " + survivor, Author: author, CreatedAt: now, BatchID: batch.ID}); err != nil {
+			return batch, err
+		}
+	}
+	return batch, tx.Commit()
+}
+
+// mergeDeal gives survivor the most recently updated deal among the merged
+// leads, unless survivor's own deal has been started.
+func mergeDeal(ctx context.Context, tx *sql.Tx, survivor string, merged []string) error {
+	var started bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT coalesce(deal_status, 'new') <> 'new' OR coalesce(deal_owner, '') <> '' OR coalesce(next_action, '') <> ''
+		FROM leads WHERE abn = ?`, survivor).Scan(&started); err != nil {
+		return err
+	}
+	if started {
+		return nil
+	}
+	in, inArgs := inList(merged)
+	from, err := queryStrings(ctx, tx, `
+		SELECT abn FROM leads
+		WHERE abn IN `+in+` AND (coalesce(deal_status, 'new') <> 'new' OR coalesce(deal_owner, '') <> '' OR coalesce(next_action, '') <> '')
+		ORDER BY deal_updated_at DESC, abn LIMIT 1`, inArgs...)
+	if err != nil || len(from) == 0 {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE leads SET
+			deal_status = (SELECT m.deal_status FROM leads m WHERE m.abn = ?),
+			deal_owner = (SELECT m.deal_owner FROM leads m WHERE m.abn = ?),
+			next_action = (SELECT m.next_action FROM leads m WHERE m.abn = ?),
+			next_action_at = (SELECT m.next_action_at FROM leads m WHERE m.abn = ?),
+			deal_updated_at = (SELECT m.deal_updated_at FROM leads m WHERE m.abn = ?)
+		WHERE abn = ?`, from[0], from[0], from[0], from[0], from[0], survivor)
+	return err
+}
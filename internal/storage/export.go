@@ -0,0 +1,395 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// ColumnKind is the type of an export column's values.
+type ColumnKind int
+
+const (
+	KindText ColumnKind = iota
+	KindURL
+	KindInt
+	KindFloat
+	KindBool
+	KindDate
+	KindTime
+)
+
+// ExportColumn is a column the lead export can include.
+type ExportColumn struct {
+	Name string
+	Kind ColumnKind
+	// expr selects the column, when it isn't simply its name
+	expr string
+}
+
+// distanceColumn is computed from the query's radius center, so it's built
+// per export rather than held as an expression.
+const distanceColumn = "distance_km"
+
+// ExportColumns lists every export column, in the default order.
+var ExportColumns = []ExportColumn{
+	{Name: "abn", Kind: KindText, expr: "leads.abn"},
+	{Name: "name", Kind: KindText},
+	{Name: "score", Kind: KindFloat},
+	{Name: "category", Kind: KindText},
+	{Name: "entity_type", Kind: KindText},
+	{Name: "entity_type_code", Kind: KindText},
+	{Name: "is_nfp", Kind: KindBool},
+	{Name: "is_charity", Kind: KindBool},
+	{Name: "charity_size", Kind: KindText},
+	{Name: "charity_responsible_persons", Kind: KindInt},
+	{Name: "entity_status", Kind: KindText},
+	{Name: "sources", Kind: KindText},
+	{Name: "state", Kind: KindText},
+	{Name: "postcode", Kind: KindText},
+	{Name: "lga", Kind: KindText},
+	{Name: "region", Kind: KindText},
+	{Name: "latitude", Kind: KindFloat},
+	{Name: "longitude", Kind: KindFloat},
+	{Name: distanceColumn, Kind: KindFloat},
+	{Name: "state_mismatch", Kind: KindBool},
+	{Name: "registration_date", Kind: KindDate},
+	{Name: "age_years", Kind: KindInt},
+	{Name: "gst_registered", Kind: KindBool},
+	{Name: "gst_effective_from", Kind: KindDate},
+	{Name: "is_current_entity", Kind: KindBool},
+	{Name: "acn", Kind: KindText},
+	{Name: "main_trading_name", Kind: KindText},
+	{Name: "phone", Kind: KindText},
+	{Name: "email", Kind: KindText},
+	{Name: "business_url", Kind: KindURL},
+	{Name: "found_at_url", Kind: KindURL},
+	{Name: "web_platform", Kind: KindText},
+	{Name: "web_https", Kind: KindBool},
+	{Name: "web_copyright_year", Kind: KindInt},
+	{Name: "web_has_store", Kind: KindBool},
+	{Name: "web_has_careers", Kind: KindBool},
+	{Name: "web_page_bytes", Kind: KindInt},
+	{Name: "web_checked_at", Kind: KindTime},
+	{Name: "anzsic_division", Kind: KindText},
+	{Name: "anzsic_subdivision", Kind: KindText},
+	{Name: "anzsic_class", Kind: KindText},
+	{Name: "anzsic_title", Kind: KindText},
+	{Name: "anzsic_confidence", Kind: KindFloat},
+	{Name: "tax_income_year", Kind: KindInt, expr: "tax.income_year"},
+	{Name: "total_income", Kind: KindInt, expr: "tax.total_income"},
+	{Name: "taxable_income", Kind: KindInt, expr: "tax.taxable_income"},
+	{Name: "tax_payable", Kind: KindInt, expr: "tax.tax_payable"},
+	{Name: "score_breakdown", Kind: KindText},
+	{Name: "score_weights", Kind: KindText},
+	{Name: "rank_score", Kind: KindFloat},
+	{Name: "rank_model", Kind: KindText},
+	{Name: "rejected_by", Kind: KindText},
+	{Name: "deal_status", Kind: KindText, expr: "coalesce(leads.deal_status, 'new')"},
+	{Name: "deal_owner", Kind: KindText},
+	{Name: "next_action", Kind: KindText},
+	{Name: "next_action_at", Kind: KindDate},
+	{Name: "last_note", Kind: KindText, expr: "(SELECT n.note FROM lead_notes n WHERE n.abn = leads.abn ORDER BY n.created_at DESC, n.id DESC LIMIT 1)"},
+	{Name: "tags", Kind: KindText, expr: "(SELECT string_agg(t.tag, ',' ORDER BY t.tag) FROM lead_tags t WHERE t.abn = leads.abn)"},
+	{Name: "lists", Kind: KindText, expr: "(SELECT string_agg(m.list, ',' ORDER BY m.list) FROM lead_list_members m WHERE m.abn = leads.abn)"},
+	{Name: "updated_at", Kind: KindTime},
+}
+
+// ParseExportColumns parses a comma-separated list of export column names,
+// keeping its order. An empty list is every column.
+func ParseExportColumns(raw string) ([]ExportColumn, error) {
+	if strings.TrimSpace(raw) == "" {
+		return ExportColumns, nil
+	}
+	byName := make(map[string]ExportColumn, len(ExportColumns))
+	for _, c := range ExportColumns {
+		byName[c.Name] = c
+	}
+	var columns []ExportColumn
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		c, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		seen[name] = true
+		columns = append(columns, c)
+	}
+	return columns, nil
+}
+
+// ExportLeads selects the given columns of the leads q selects, leaving out
+// any the suppression list covers. Nil columns is every column.
+func (r *sqlRepo) ExportLeads(ctx context.Context, q LeadQuery, columns []ExportColumn) (*ExportRows, error) {
+	if len(columns) == 0 {
+		columns = ExportColumns
+	}
+	where, args, err := r.exportWhere(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	exprs, selectArgs := exportExprs(q, columns)
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s %s", strings.Join(exprs, ", "), leadsFrom, where, q.orderBy())
+	rows, err := r.db.QueryContext(ctx, query, append(selectArgs, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return newExportRows(rows, columns, 0), nil
+}
+
+// exportExprs returns the select list for columns, with the placeholder args
+// it needs.
+func exportExprs(q LeadQuery, columns []ExportColumn) ([]string, []interface{}) {
+	var args []interface{}
+	exprs := make([]string, len(columns))
+	for i, c := range columns {
+		switch {
+		case c.Name == distanceColumn:
+			distance := "NULL"
+			if q.Radius != nil {
+				distance, args = DistanceSQL(q.Radius.Center)
+			}
+			exprs[i] = fmt.Sprintf("round(%s, 1) AS %s", distance, c.Name)
+		case c.expr != "":
+			exprs[i] = c.expr + " AS " + c.Name
+		default:
+			exprs[i] = c.Name
+		}
+	}
+	return exprs, args
+}
+
+// ExportableLeads returns the leads q selects, in its order, leaving out any
+// the suppression list covers. Exports that need the whole lead use it.
+func (r *sqlRepo) ExportableLeads(ctx context.Context, q LeadQuery) ([]model.Lead, error) {
+	where, args, err := r.exportWhere(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE %s %s", leadColumns, leadsFrom, where, q.orderBy()), args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanLeads(rows)
+}
+
+// exportWhere is q's filter with suppressed leads excluded, since they never
+// leave the database.
+func (r *sqlRepo) exportWhere(ctx context.Context, q LeadQuery) (string, []interface{}, error) {
+	now := time.Now()
+	where, args := q.where(now)
+	suppressed, suppressedArgs, err := r.suppressionFilter(ctx, now)
+	if err != nil {
+		return "", nil, err
+	}
+	if suppressed != "" {
+		where = "(" + where + ") AND NOT " + suppressed
+		args = append(args, suppressedArgs...)
+	}
+	return where, args, nil
+}
+
+// suppressionFilter returns a condition matching the leads the active
+// suppressions cover, or "" if there are none.
+func (r *sqlRepo) suppressionFilter(ctx context.Context, now time.Time) (string, []interface{}, error) {
+	suppressions, err := r.ListSuppressions(ctx, false)
+	if err != nil {
+		return "", nil, fmt.Errorf("could not load suppressions: %w", err)
+	}
+	if len(suppressions) == 0 {
+		return "", nil, nil
+	}
+	cond, args := suppressedSQL(suppressions, now)
+	return cond, args, nil
+}
+
+// ExportRows is the result of ExportLeads, read like sql.Rows. Each value is
+// nil or its column kind's Go type: string for text and URLs, int64, float64,
+// bool, or a UTC time.Time for dates and times. The backends return their
+// own types, so they're converted here.
+type ExportRows struct {
+	rows    *sql.Rows
+	columns []ExportColumn
+	raw     []interface{}
+	dest    []interface{}
+	values  []interface{}
+	err     error
+	// For a delta export, the change each lead was read with, by ABN. The
+	// ABN is selected after the columns, unseen.
+	changes map[string]model.ExportChange
+	// For a delta export, handed-off leads since deleted, read as drops
+	// once the rows run out
+	gone []string
+}
+
+// newExportRows reads rows of columns, followed by hidden columns the
+// caller reads itself.
+func newExportRows(rows *sql.Rows, columns []ExportColumn, hidden int) *ExportRows {
+	r := &ExportRows{
+		rows:    rows,
+		columns: columns,
+		raw:     make([]interface{}, len(columns)+hidden),
+		dest:    make([]interface{}, len(columns)+hidden),
+		values:  make([]interface{}, len(columns)),
+	}
+	for i := range r.raw {
+		r.dest[i] = &r.raw[i]
+	}
+	return r
+}
+
+// Columns returns the exported columns, in the order Values returns them.
+func (r *ExportRows) Columns() []ExportColumn {
+	return r.columns
+}
+
+// Next advances to the next lead, returning false at the end or on error.
+func (r *ExportRows) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	if !r.rows.Next() {
+		if r.rows.Err() != nil || len(r.gone) == 0 {
+			return false
+		}
+		r.nextGone()
+		return true
+	}
+	if r.err = r.rows.Scan(r.dest...); r.err != nil {
+		return false
+	}
+	for i, c := range r.columns {
+		v, err := convertExportValue(r.raw[i], c.Kind)
+		if err != nil {
+			r.err = fmt.Errorf("column %s: %w", c.Name, err)
+			return false
+		}
+		r.values[i] = v
+	}
+	if r.changes != nil {
+		abn, err := convertExportValue(r.raw[len(r.columns)], KindText)
+		if err != nil {
+			r.err = fmt.Errorf("abn: %w", err)
+			return false
+		}
+		change, _ := r.values[0].(string)
+		r.changes[abn.(string)] = model.ExportChange(change)
+	}
+	return true
+}
+
+// nextGone makes the next deleted lead the current row, dropped, with its
+// ABN the only value known.
+func (r *ExportRows) nextGone() {
+	abn := r.gone[0]
+	r.gone = r.gone[1:]
+	for i, c := range r.columns {
+		r.values[i] = nil
+		if c.Name == "abn" {
+			r.values[i] = abn
+		}
+	}
+	r.values[0] = string(model.ExportDropped)
+	r.changes[abn] = model.ExportDropped
+}
+
+// Changes returns, for a delta export, the change each lead read so far was
+// exported as, by ABN. It is nil for other exports.
+func (r *ExportRows) Changes() map[string]model.ExportChange {
+	return r.changes
+}
+
+// Values returns the current lead's values. The slice is reused by Next.
+func (r *ExportRows) Values() []interface{} {
+	return r.values
+}
+
+// Err returns the error, if any, that ended iteration.
+func (r *ExportRows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *ExportRows) Close() error {
+	return r.rows.Close()
+}
+
+// convertExportValue converts a scanned value to its kind's Go type. SQLite
+// has no boolean type, and hands back times it doesn't know are times as
+// text.
+func convertExportValue(v interface{}, kind ColumnKind) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	switch kind {
+	case KindText, KindURL:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return fmt.Sprint(v), nil
+	case KindInt:
+		switch x := v.(type) {
+		case int64:
+			return x, nil
+		case int32:
+			return int64(x), nil
+		case int:
+			return int64(x), nil
+		case float64:
+			return int64(x), nil
+		case string:
+			return strconv.ParseInt(x, 10, 64)
+		}
+	case KindFloat:
+		switch x := v.(type) {
+		case float64:
+			return x, nil
+		case float32:
+			return float64(x), nil
+		case int64:
+			return float64(x), nil
+		case int32:
+			return float64(x), nil
+		case string:
+			return strconv.ParseFloat(x, 64)
+		}
+	case KindBool:
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case int64:
+			return x != 0, nil
+		case int32:
+			return x != 0, nil
+		case string:
+			return strconv.ParseBool(x)
+		}
+	case KindDate, KindTime:
+		switch x := v.(type) {
+		case time.Time:
+			return x.UTC(), nil
+		case string:
+			for _, layout := range []string{"2006-01-02 15:04:05.999999999-07:00", "2006-01-02 15:04:05.999999999", "2006-01-02T15:04:05.999999999Z07:00", "2006-01-02"} {
+				if t, err := time.Parse(layout, x); err == nil {
+					return t.UTC(), nil
+				}
+			}
+		}
+	}
+	return nil, fmt.Errorf("unexpected %T value %v", v, v)
+}
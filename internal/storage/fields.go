@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// GetFieldValues returns every value asserted for a lead's fields.
+func (r *sqlRepo) GetFieldValues(ctx context.Context, abn string) ([]model.FieldValue, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT field, value, asserter, asserted_at
+		FROM lead_field_values WHERE abn = ? ORDER BY field, asserted_at, asserter`, abn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []model.FieldValue
+	for rows.Next() {
+		var v model.FieldValue
+		var at sql.NullTime
+		if err := rows.Scan(&v.Field, &v.Value, &v.Asserter, &at); err != nil {
+			return nil, err
+		}
+		v.AssertedAt = at.Time
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// saveFieldValues upserts the lead's assertions. A value asserted again keeps
+// the time it was first asserted, so "newest wins" means newest to change.
+func saveFieldValues(ctx context.Context, tx *sql.Tx, l model.Lead) error {
+	for _, v := range l.Assertions {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO lead_field_values (abn, field, asserter, value, asserted_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (abn, field, asserter) DO UPDATE SET
+				asserted_at = CASE WHEN lead_field_values.value = EXCLUDED.value THEN lead_field_values.asserted_at ELSE EXCLUDED.asserted_at END,
+				value = EXCLUDED.value`,
+			l.ABN, v.Field, v.Asserter, v.Value, v.AssertedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillFieldValues records the stored values of leads saved before field
+// assertions were, as asserted by "stored" at the lead's last update.
+func (r *sqlRepo) backfillFieldValues(ctx context.Context, tx *sql.Tx) error {
+	// Only columns the leads table had when this backfill was written
+	rows, err := tx.QueryContext(ctx, `
+		SELECT abn, coalesce(name, ''), coalesce(category, ''), coalesce(state, ''), coalesce(postcode, ''),
+			coalesce(phone, ''), coalesce(email, ''), coalesce(business_url, ''), coalesce(main_trading_name, ''), updated_at
+		FROM leads WHERE abn NOT IN (SELECT abn FROM lead_field_values)`)
+	if err != nil {
+		return err
+	}
+	var leads []model.Lead
+	for rows.Next() {
+		var l model.Lead
+		var updated sql.NullTime
+		if err := rows.Scan(&l.ABN, &l.Name, &l.Category, &l.State, &l.Postcode,
+			&l.Phone, &l.Email, &l.BusinessURL, &l.MainTradingName, &updated); err != nil {
+			rows.Close()
+			return err
+		}
+		l.UpdatedAt = updated.Time
+		leads = append(leads, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, l := range leads {
+		at := l.UpdatedAt
+		if at.IsZero() {
+			at = now
+		}
+		l.AssertFields(model.AsserterStored, at)
+		if err := saveFieldValues(ctx, tx, l); err != nil {
+			return err
+		}
+	}
+	if len(leads) > 0 {
+		r.logger.Info("Backfilled field values", "leads", len(leads))
+	}
+	return nil
+}
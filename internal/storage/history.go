@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// historyColumns are the lead columns whose changes SaveLead records in
+// lead_history.
+var historyColumns = []string{
+	"entity_status", "is_current_entity", "gst_registered", "gst_effective_from",
+	"name", "main_trading_name", "state", "postcode", "phone", "email", "business_url",
+}
+
+// NewRunID returns an ID for one pipeline run, sortable by start time.
+func NewRunID(start time.Time) string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return start.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+// SetRunID tags the changes later saves record with the run making them.
+func (r *sqlRepo) SetRunID(id string) {
+	r.runID = id
+}
+
+// historySnapshot reads the tracked columns of a stored lead as text, or nil
+// if there's no such lead.
+func historySnapshot(ctx context.Context, tx *sql.Tx, abn string) (map[string]string, error) {
+	var status, name, tradingName, state, postcode, phone, email, url sql.NullString
+	var current, gst sql.NullBool
+	var gstFrom sql.NullTime
+	err := tx.QueryRowContext(ctx, "SELECT "+strings.Join(historyColumns, ", ")+" FROM leads WHERE abn = ?", abn).Scan(
+		&status, &current, &gst, &gstFrom, &name, &tradingName, &state, &postcode, &phone, &email, &url)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	boolText := func(b sql.NullBool) string {
+		if !b.Valid {
+			return ""
+		}
+		return fmt.Sprint(b.Bool)
+	}
+	dateText := func(t sql.NullTime) string {
+		if !t.Valid || t.Time.Year() <= 1 {
+			return ""
+		}
+		return t.Time.Format("2006-01-02")
+	}
+	return map[string]string{
+		"entity_status":      status.String,
+		"is_current_entity":  boolText(current),
+		"gst_registered":     boolText(gst),
+		"gst_effective_from": dateText(gstFrom),
+		"name":               name.String,
+		"main_trading_name":  tradingName.String,
+		"state":              state.String,
+		"postcode":           postcode.String,
+		"phone":              phone.String,
+		"email":              email.String,
+		"business_url":       url.String,
+	}, nil
+}
+
+// recordChanges writes a lead_history row for each tracked column that
+// differs between two snapshots of a lead.
+func recordChanges(ctx context.Context, tx *sql.Tx, abn, runID string, before, after map[string]string, at time.Time) error {
+	if before == nil || after == nil {
+		return nil
+	}
+	for _, col := range historyColumns {
+		if strings.TrimSpace(before[col]) == strings.TrimSpace(after[col]) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO lead_history (abn, run_id, field, old_value, new_value, changed_at)
+			VALUES (?, ?, ?, ?, ?, ?)`,
+			abn, nullString(runID), col, before[col], after[col], at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListChanges returns the changes recorded in [from, to), oldest first.
+func (r *sqlRepo) ListChanges(ctx context.Context, from, to time.Time) ([]model.LeadChange, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT h.abn, coalesce(l.name, ''), coalesce(h.run_id, ''), h.field, coalesce(h.old_value, ''), coalesce(h.new_value, ''), h.changed_at
+		FROM lead_history h
+		LEFT JOIN leads l ON l.abn = h.abn
+		WHERE h.changed_at >= ? AND h.changed_at < ?
+		ORDER BY h.changed_at, h.abn, h.field`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []model.LeadChange
+	for rows.Next() {
+		var c model.LeadChange
+		if err := rows.Scan(&c.ABN, &c.Name, &c.RunID, &c.Field, &c.OldValue, &c.NewValue, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
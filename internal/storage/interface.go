@@ -2,13 +2,95 @@ package storage
 
 import (
 	"context"
+	"time"
 
+	"github.com/shanehull/sourcerer/internal/geo"
 	"github.com/shanehull/sourcerer/internal/model"
 )
 
+// Repository is the lead store the commands work against.
 type Repository interface {
 	Init(ctx context.Context) error
-	SaveLead(ctx context.Context, lead model.Lead) error
-	ExportCSV(ctx context.Context, path string) error
 	Close() error
+	SetRunID(id string)
+
+	// Schema
+	Migrate(ctx context.Context) ([]Migration, error)
+	SchemaVersion(ctx context.Context) (int, error)
+	AppliedMigrations(ctx context.Context) ([]AppliedMigration, error)
+
+	// Leads
+	SaveLead(ctx context.Context, lead model.Lead) (bool, error)
+	GetLead(ctx context.Context, abn string) (*model.Lead, error)
+	GetLeadByName(ctx context.Context, name string) (*model.Lead, error)
+	LookupLead(ctx context.Context, abn, name string) (*model.Lead, error)
+	GetLeadSources(ctx context.Context, abn string) ([]model.SourceRecord, error)
+	GetFieldValues(ctx context.Context, abn string) ([]model.FieldValue, error)
+	ListLeads(ctx context.Context) ([]model.Lead, error)
+	FindLeads(ctx context.Context, q LeadQuery) ([]model.Lead, error)
+	CountLeads(ctx context.Context, q LeadQuery) (int, error)
+	ExportLeads(ctx context.Context, q LeadQuery, columns []ExportColumn) (*ExportRows, error)
+	ExportableLeads(ctx context.Context, q LeadQuery) ([]model.Lead, error)
+	ExportDelta(ctx context.Context, q LeadQuery, columns []ExportColumn, watermark string) (*ExportRows, error)
+	ClassifyUnclassified(ctx context.Context, classify func(*model.Lead)) (int, error)
+	InspectUninspected(ctx context.Context, q LeadQuery, inspect func(*model.Lead)) (int, error)
+	LocateUnlocated(ctx context.Context, data *geo.Dataset) (int, error)
+	Rescore(ctx context.Context, weights string, score func(*model.Lead) model.Score) (int, error)
+	ListChanges(ctx context.Context, from, to time.Time) ([]model.LeadChange, error)
+
+	// Deletes, snapshotted so they can be undone
+	DeleteLeads(ctx context.Context, q LeadQuery, author string, suppress *model.Suppression) (model.DeleteBatch, error)
+	UndoDelete(ctx context.Context, batchID string) (restored int, skipped []string, err error)
+	ListDeleteBatches(ctx context.Context) ([]model.DeleteBatch, error)
+	PurgeDeleteBatches(ctx context.Context, cutoff time.Time) (int, error)
+
+	// Backups
+	Backup(ctx context.Context, dir string) error
+	Restore(ctx context.Context, dir string) error
+
+	// Deal pipeline
+	SetDeal(ctx context.Context, abn string, d model.Deal) error
+	AddLeadNote(ctx context.Context, n model.LeadNote) (string, error)
+	ListLeadNotes(ctx context.Context, abn string) ([]model.LeadNote, error)
+
+	// Tags and lists
+	TagLeads(ctx context.Context, q LeadQuery, tags []string, author string) (int, error)
+	UntagLeads(ctx context.Context, q LeadQuery, tags []string) (int, error)
+	ListTags(ctx context.Context) ([]model.TagCount, error)
+	GetLeadTags(ctx context.Context, abn string) ([]string, error)
+	AddToList(ctx context.Context, name string, q LeadQuery, author string) (int, error)
+	RemoveFromList(ctx context.Context, name string, q LeadQuery) (int, error)
+	DeleteList(ctx context.Context, name string) (bool, error)
+	GetList(ctx context.Context, name string) (*model.LeadList, error)
+	ListLists(ctx context.Context) ([]model.LeadList, error)
+
+	// Duplicates
+	FindDuplicates(ctx context.Context) ([]model.DuplicatePair, error)
+	RejectDuplicate(ctx context.Context, a, b, author string) error
+	ListDuplicateRejections(ctx context.Context) ([]model.DuplicateRejection, error)
+	MergeLeads(ctx context.Context, survivor string, merged []string, author string) (model.DeleteBatch, error)
+
+	// Suppressions
+	AddSuppression(ctx context.Context, s model.Suppression) (string, error)
+	RemoveSuppression(ctx context.Context, id string) (bool, error)
+	ListSuppressions(ctx context.Context, includeExpired bool) (model.Suppressions, error)
+
+	// Export watermarks
+	GetExportWatermark(ctx context.Context, name string) (*model.ExportWatermark, error)
+	AdvanceExportWatermark(ctx context.Context, name string, at time.Time, changes map[string]model.ExportChange) error
+
+	// Reference registers
+	GetCharity(ctx context.Context, abn string) (*model.Charity, error)
+	ImportCharities(ctx context.Context, charities []model.Charity) (int, error)
+	GetLatestTaxRecord(ctx context.Context, abn string) (*model.TaxRecord, error)
+	ImportTaxRecords(ctx context.Context, incomeYear int, records []model.TaxRecord) (int, error)
+
+	// Outcomes and ranking
+	RecordOutcome(ctx context.Context, o model.LeadOutcome) error
+	ListOutcomes(ctx context.Context) ([]model.LeadOutcome, error)
+	SaveRankModel(ctx context.Context, id string, trainedAt time.Time, data []byte) error
+	LatestRankModel(ctx context.Context) ([]byte, error)
+	SetRankScores(ctx context.Context, modelID string, scores map[string]float64) error
 }
+
+var _ Repository = (*SQLiteRepo)(nil)
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/shanehull/sourcerer/internal/geo"
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// LocateUnlocated places stored leads that have a postcode but no location,
+// such as those saved before locations were, from data. Leads data can't
+// place are tried again each time, so a fuller dataset picks them up. It
+// returns how many it placed.
+func (r *sqlRepo) LocateUnlocated(ctx context.Context, data *geo.Dataset) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	located, err := locateUnlocated(ctx, tx, data)
+	if err != nil {
+		return 0, err
+	}
+	return located, tx.Commit()
+}
+
+func locateUnlocated(ctx context.Context, tx *sql.Tx, data *geo.Dataset) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT abn, postcode FROM leads
+		WHERE coalesce(postcode, '') <> '' AND latitude IS NULL AND longitude IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+	var leads []model.Lead
+	for rows.Next() {
+		var l model.Lead
+		if err := rows.Scan(&l.ABN, &l.Postcode); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		leads = append(leads, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	located := 0
+	for _, l := range leads {
+		if !l.Locate(data) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE leads SET lga = ?, region = ?, latitude = ?, longitude = ? WHERE abn = ?",
+			l.LGA, l.Region, l.Latitude, l.Longitude, l.ABN); err != nil {
+			return located, err
+		}
+		located++
+	}
+	return located, nil
+}
+
+// backfillLocations places leads saved before locations were, from the
+// bundled postcode data.
+func (r *sqlRepo) backfillLocations(ctx context.Context, tx *sql.Tx) error {
+	located, err := locateUnlocated(ctx, tx, geo.Default())
+	if err != nil {
+		return err
+	}
+	if located > 0 {
+		r.logger.Info("Backfilled lead locations", "leads", located)
+	}
+	return nil
+}
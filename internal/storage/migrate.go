@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations are numbered NNN_name.sql and applied in order, each once. Never
+// edit one that has shipped; add a new one instead. Every statement is
+// idempotent, so databases from before schema_migrations existed adopt the
+// history without losing data.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	sql     string
+}
+
+// AppliedMigration is a row of schema_migrations.
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// dataMigrations run in Go after the SQL of their version, in its
+// transaction, for backfills SQL can't express.
+var dataMigrations = map[int]func(*sqlRepo, context.Context, *sql.Tx) error{
+	5:  (*sqlRepo).backfillEntityTypeCodes,
+	10: (*sqlRepo).backfillLeadSources,
+	11: (*sqlRepo).backfillFieldValues,
+	15: (*sqlRepo).backfillLeadNames,
+	24: (*sqlRepo).backfillLocations,
+}
+
+// Migrations returns the embedded migrations in order.
+func Migrations() []Migration {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		panic(fmt.Sprintf("storage: embedded migrations are unreadable: %v", err))
+	}
+	var out []Migration
+	for _, e := range entries {
+		base := strings.TrimSuffix(e.Name(), ".sql")
+		num, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil {
+			panic(fmt.Sprintf("storage: migration %s isn't named NNN_name.sql", e.Name()))
+		}
+		data, err := migrationFiles.ReadFile("migrations/" + e.Name())
+		if err != nil {
+			panic(fmt.Sprintf("storage: migration %s is unreadable: %v", e.Name(), err))
+		}
+		out = append(out, Migration{Version: version, Name: name, sql: string(data)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	for i := range out {
+		if out[i].Version != i+1 {
+			panic(fmt.Sprintf("storage: migration %d is missing", i+1))
+		}
+	}
+	return out
+}
+
+// LatestVersion is the schema version this binary migrates to.
+func LatestVersion() int {
+	m := Migrations()
+	return m[len(m)-1].Version
+}
+
+// SchemaTooNewError is returned for a database migrated by a newer binary.
+type SchemaTooNewError struct {
+	Version int
+	Latest  int
+}
+
+func (e *SchemaTooNewError) Error() string {
+	return fmt.Sprintf("database schema is version %d but this build only knows up to %d; upgrade before using it", e.Version, e.Latest)
+}
+
+func (r *sqlRepo) ensureMigrationsTable(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT,
+		applied_at TIMESTAMP
+	);`)
+	return err
+}
+
+// SchemaVersion returns the highest applied migration, 0 for a new database.
+func (r *sqlRepo) SchemaVersion(ctx context.Context) (int, error) {
+	if err := r.ensureMigrationsTable(ctx); err != nil {
+		return 0, err
+	}
+	var version sql.NullInt64
+	err := r.db.QueryRowContext(ctx, "SELECT max(version) FROM schema_migrations").Scan(&version)
+	return int(version.Int64), err
+}
+
+// AppliedMigrations returns the migrations recorded in schema_migrations.
+func (r *sqlRepo) AppliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
+	if err := r.ensureMigrationsTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, "SELECT version, coalesce(name, ''), applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var m AppliedMigration
+		var at sql.NullTime
+		if err := rows.Scan(&m.Version, &m.Name, &at); err != nil {
+			return nil, err
+		}
+		m.AppliedAt = at.Time
+		applied = append(applied, m)
+	}
+	return applied, rows.Err()
+}
+
+// Migrate applies pending migrations and returns those it applied. It refuses
+// to touch a database whose schema is newer than this build.
+func (r *sqlRepo) Migrate(ctx context.Context) ([]Migration, error) {
+	current, err := r.SchemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	migrations := Migrations()
+	if latest := migrations[len(migrations)-1].Version; current > latest {
+		return nil, &SchemaTooNewError{Version: current, Latest: latest}
+	}
+
+	var applied []Migration
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := r.apply(ctx, m); err != nil {
+			return applied, fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+		r.logger.Info("Applied migration", "version", m.Version, "name", m.Name)
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+func (r *sqlRepo) apply(ctx context.Context, m Migration) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmts := m.sql
+	if r.dialect.migrationSQL != nil {
+		stmts = r.dialect.migrationSQL(stmts)
+	}
+	// A migration whose work is all in its backfill is only comments, which
+	// DuckDB won't run as a query
+	if hasStatements(stmts) {
+		if _, err := tx.ExecContext(ctx, stmts); err != nil {
+			return err
+		}
+	}
+	// The backfill and the version go in with the schema change, so a
+	// failure leaves the migration wholly unapplied and free to retry
+	if backfill, ok := dataMigrations[m.Version]; ok {
+		if err := backfill(r, ctx, tx); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)", m.Version, m.Name, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// hasStatements reports whether sql has anything besides comments and
+// blank lines.
+func hasStatements(sql string) bool {
+	for _, line := range strings.Split(sql, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return true
+		}
+	}
+	return false
+}
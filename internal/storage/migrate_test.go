@@ -0,0 +1,85 @@
+package storage_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/shanehull/sourcerer/internal/geo"
+	"github.com/shanehull/sourcerer/internal/model"
+	"github.com/shanehull/sourcerer/internal/storage"
+)
+
+// testRadiusAfterMigration migrates a database from before leads had
+// locations and checks a radius search places and measures its leads.
+// driver opens the fixture directly; prefix opens it as a repository.
+func testRadiusAfterMigration(t *testing.T, driver, prefix string) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "leads.db")
+	fixture, err := os.ReadFile("testdata/pre_location.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, string(fixture)); err != nil {
+		t.Fatalf("load fixture: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := storage.Open(prefix+path, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	if err := repo.Init(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	// Dandenong and Melbourne are within 40km of 3175; Sydney isn't, and
+	// 2999 isn't in the postcode data
+	center, err := geo.Default().ParsePoint("3175")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := storage.LeadQuery{Radius: &model.Radius{Center: center, Km: 40}, Sort: storage.SortName}
+	columns, err := storage.ParseExportColumns("abn,lga,distance_km")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := repo.ExportLeads(ctx, q, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var abns []string
+	for rows.Next() {
+		v := rows.Values()
+		abns = append(abns, v[0].(string))
+		if v[1] == nil || v[1] == "" {
+			t.Errorf("%s has no LGA", v[0])
+		}
+		if d, ok := v[2].(float64); !ok || d > 40 {
+			t.Errorf("%s is %v km away, want within 40", v[0], v[2])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"11000000001", "22000000002"}; !slices.Equal(abns, want) {
+		t.Errorf("within 40km of 3175 found %v, want %v", abns, want)
+	}
+}
+
+func TestRadiusAfterMigrationSQLite(t *testing.T) {
+	testRadiusAfterMigration(t, "sqlite", "sqlite:")
+}
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS leads (
+	abn TEXT PRIMARY KEY,
+	name TEXT,
+	category TEXT,
+	sources TEXT,
+	entity_type TEXT,
+	entity_status TEXT,
+	state TEXT,
+	postcode TEXT,
+	registration_date TIMESTAMP,
+	age_years INTEGER,
+	gst_registered BOOLEAN,
+	gst_effective_from TIMESTAMP,
+	is_current_entity BOOLEAN,
+	acn TEXT,
+	main_trading_name TEXT,
+	phone TEXT,
+	email TEXT,
+	business_url TEXT,
+	found_at_url TEXT,
+	updated_at TIMESTAMP
+);
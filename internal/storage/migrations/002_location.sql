@@ -0,0 +1,5 @@
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS lga TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS region TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS latitude DOUBLE;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS longitude DOUBLE;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS state_mismatch BOOLEAN;
@@ -0,0 +1,7 @@
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS web_checked_at TIMESTAMP;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS web_platform TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS web_https BOOLEAN;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS web_copyright_year INTEGER;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS web_has_store BOOLEAN;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS web_has_careers BOOLEAN;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS web_page_bytes INTEGER;
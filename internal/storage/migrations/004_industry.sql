@@ -0,0 +1,7 @@
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS web_title TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS web_description TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS anzsic_division TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS anzsic_subdivision TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS anzsic_class TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS anzsic_title TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS anzsic_confidence DOUBLE;
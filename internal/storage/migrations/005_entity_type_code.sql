@@ -0,0 +1,2 @@
+-- Codes for existing leads are derived from entity_type in Go
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS entity_type_code TEXT;
@@ -0,0 +1,15 @@
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS is_nfp BOOLEAN;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS is_charity BOOLEAN;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS charity_size TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS charity_responsible_persons INTEGER;
+
+-- The ACNC register, imported with cmd/import
+CREATE TABLE IF NOT EXISTS charities (
+	abn TEXT PRIMARY KEY,
+	legal_name TEXT,
+	size TEXT,
+	responsible_persons INTEGER,
+	registration_date TIMESTAMP,
+	website TEXT,
+	imported_at TIMESTAMP
+);
@@ -0,0 +1,16 @@
+-- ATO tax transparency data, imported with cmd/import
+CREATE TABLE IF NOT EXISTS tax_transparency (
+	abn TEXT,
+	income_year INTEGER,
+	name TEXT,
+	total_income BIGINT,
+	taxable_income BIGINT,
+	tax_payable BIGINT,
+	imported_at TIMESTAMP,
+	PRIMARY KEY (abn, income_year)
+);
+
+CREATE VIEW IF NOT EXISTS latest_tax_transparency AS
+SELECT abn, income_year, total_income, taxable_income, tax_payable
+FROM tax_transparency t
+WHERE income_year = (SELECT max(income_year) FROM tax_transparency t2 WHERE t2.abn = t.abn);
@@ -0,0 +1,4 @@
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS score DOUBLE;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS score_breakdown TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS score_weights TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS scored_at TIMESTAMP;
@@ -0,0 +1,15 @@
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS rank_score DOUBLE;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS rank_model TEXT;
+
+CREATE TABLE IF NOT EXISTS lead_outcomes (
+	abn TEXT,
+	outcome TEXT,
+	note TEXT,
+	recorded_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS rank_models (
+	id TEXT PRIMARY KEY,
+	trained_at TIMESTAMP,
+	model TEXT
+);
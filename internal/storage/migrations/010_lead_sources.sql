@@ -0,0 +1,11 @@
+-- Existing leads are split out of leads.sources in Go
+CREATE TABLE IF NOT EXISTS lead_sources (
+	abn TEXT,
+	source TEXT,
+	found_at_url TEXT,
+	category TEXT,
+	raw_name TEXT,
+	first_seen TIMESTAMP,
+	last_seen TIMESTAMP,
+	PRIMARY KEY (abn, source)
+);
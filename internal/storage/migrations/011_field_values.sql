@@ -0,0 +1,9 @@
+-- Existing leads' values are recorded as asserted by "stored" in Go
+CREATE TABLE IF NOT EXISTS lead_field_values (
+	abn TEXT,
+	field TEXT,
+	asserter TEXT,
+	value TEXT,
+	asserted_at TIMESTAMP,
+	PRIMARY KEY (abn, field, asserter)
+);
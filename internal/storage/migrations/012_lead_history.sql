@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS lead_history (
+	abn TEXT,
+	run_id TEXT,
+	field TEXT,
+	old_value TEXT,
+	new_value TEXT,
+	changed_at TIMESTAMP
+);
@@ -0,0 +1,12 @@
+-- Leads the pipeline skips and exports leave out. A row matches by ABN or by
+-- name pattern; a NULL source applies to every source.
+CREATE TABLE IF NOT EXISTS suppressions (
+	id TEXT PRIMARY KEY,
+	abn TEXT,
+	name_pattern TEXT,
+	source TEXT,
+	reason TEXT,
+	author TEXT,
+	created_at TIMESTAMP,
+	expires_at TIMESTAMP
+);
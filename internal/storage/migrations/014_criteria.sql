@@ -0,0 +1,5 @@
+-- The pipeline stores every enriched lead with its selection results, so
+-- selection is left to export queries. Leads saved before this were all
+-- selected and have NULLs.
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS criteria TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS rejected_by TEXT;
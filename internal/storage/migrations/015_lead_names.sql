@@ -0,0 +1,12 @@
+-- Normalized names, trading names and listing names of each lead, for cache
+-- lookups before the ABN is known. A lead keeps the names it used to have.
+CREATE TABLE IF NOT EXISTS lead_names (
+	abn TEXT,
+	name_key TEXT,
+	kind TEXT,
+	PRIMARY KEY (abn, name_key)
+);
+
+-- When the ABR last confirmed the lead; updated_at moves on every save.
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS enriched_at TIMESTAMP;
+UPDATE leads SET enriched_at = updated_at WHERE enriched_at IS NULL;
@@ -0,0 +1,16 @@
+-- Named export hand-offs for delta exports: when each last handed off, and
+-- which leads it has handed off and whether they're still in.
+CREATE TABLE IF NOT EXISTS export_watermarks (
+	name TEXT PRIMARY KEY,
+	exported_at TIMESTAMP,
+	run_id TEXT
+);
+
+CREATE TABLE IF NOT EXISTS export_watermark_leads (
+	watermark TEXT,
+	abn TEXT,
+	in_export BOOLEAN,
+	change_type TEXT,
+	exported_at TIMESTAMP,
+	PRIMARY KEY (watermark, abn)
+);
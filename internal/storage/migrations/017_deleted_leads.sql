@@ -0,0 +1,18 @@
+-- Deletes keep a snapshot of what they removed so they can be undone. Each
+-- batch is one delete; its leads' rows, from leads and the tables keyed by
+-- ABN, are kept as JSON so later migrations don't strand them.
+CREATE TABLE IF NOT EXISTS delete_batches (
+	id TEXT PRIMARY KEY,
+	deleted_at TIMESTAMP,
+	author TEXT,
+	filters TEXT,
+	leads INTEGER,
+	restored_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS deleted_leads (
+	batch_id TEXT,
+	abn TEXT,
+	source_table TEXT,
+	row_data TEXT
+);
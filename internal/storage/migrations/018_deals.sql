@@ -0,0 +1,16 @@
+-- The deal pipeline: where the team is with each lead. These columns are
+-- entered by hand and SaveLead never writes them, so a re-scrape leaves them
+-- be. A NULL status is a new lead.
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS deal_status TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS deal_owner TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS next_action_at TIMESTAMP;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS next_action TEXT;
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS deal_updated_at TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS lead_notes (
+	id TEXT PRIMARY KEY,
+	abn TEXT,
+	note TEXT,
+	author TEXT,
+	created_at TIMESTAMP
+);
@@ -0,0 +1,22 @@
+-- Hand-made grouping of leads: tags, many to many, and named static lists,
+-- e.g. a quarter's shortlist. Tags are stored normalised, see model.NormalizeTag.
+CREATE TABLE IF NOT EXISTS lead_tags (
+	abn TEXT,
+	tag TEXT,
+	author TEXT,
+	tagged_at TIMESTAMP,
+	PRIMARY KEY (abn, tag)
+);
+
+CREATE TABLE IF NOT EXISTS lead_lists (
+	name TEXT PRIMARY KEY,
+	author TEXT,
+	created_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS lead_list_members (
+	list TEXT,
+	abn TEXT,
+	added_at TIMESTAMP,
+	PRIMARY KEY (list, abn)
+);
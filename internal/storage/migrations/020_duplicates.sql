@@ -0,0 +1,9 @@
+-- Pairs of leads reviewed and found not to be the same business, so
+-- duplicate detection stops offering them. abn_a sorts before abn_b.
+CREATE TABLE IF NOT EXISTS duplicate_rejections (
+	abn_a TEXT,
+	abn_b TEXT,
+	author TEXT,
+	rejected_at TIMESTAMP,
+	PRIMARY KEY (abn_a, abn_b)
+);
@@ -0,0 +1,3 @@
+-- When classification last ran over a lead, matched or not, so leads that
+-- match no class aren't retried on every run until they're saved again.
+ALTER TABLE leads ADD COLUMN IF NOT EXISTS anzsic_classified_at TIMESTAMP;
@@ -0,0 +1,3 @@
+-- Leads without a GST effective date were stored with the zero time, which
+-- the GST filter took for a date. They're NULL now, as SaveLead stores them.
+UPDATE leads SET gst_effective_from = NULL WHERE gst_effective_from < '1900-01-01';
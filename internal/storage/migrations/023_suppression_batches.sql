@@ -0,0 +1,3 @@
+-- The delete batch that added a suppression, for delete -suppress and
+-- merges, so undoing the batch lifts it again. NULL for ones added directly.
+ALTER TABLE suppressions ADD COLUMN IF NOT EXISTS batch_id TEXT;
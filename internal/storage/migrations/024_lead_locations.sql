@@ -0,0 +1,4 @@
+-- Leads saved before 002 have a postcode but no location, so area and radius
+-- filters would leave them all out. They're placed from the bundled postcode
+-- data in Go, as are any the pipeline stores later with a postcode it lacks
+-- once a fuller dataset is loaded.
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// Kinds of lead_names row, in the order lookups prefer them
+const (
+	nameKindName    = "name"
+	nameKindTrading = "trading_name"
+	nameKindListing = "listing" // Name a source listed the lead under
+)
+
+// LookupLead finds a stored lead by ABN or, failing that, by a name matching
+// its registered name, trading name or a name a source listed it under. Names
+// match once normalized, so "ACME P/L" finds "Acme Pty Ltd". It returns nil
+// if there's no match.
+func (r *sqlRepo) LookupLead(ctx context.Context, abn, name string) (*model.Lead, error) {
+	if abn != "" {
+		l, err := r.GetLead(ctx, abn)
+		if l != nil || err != nil {
+			return l, err
+		}
+	}
+	key := model.NormalizeName(name)
+	if key == "" {
+		return nil, nil
+	}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT abn FROM lead_names WHERE name_key = ?
+		ORDER BY CASE kind WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END, abn
+		LIMIT 1`, key, nameKindName, nameKindTrading).Scan(&abn)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetLead(ctx, abn)
+}
+
+// saveLeadNames records the names a saved lead goes by. Run it after the
+// lead and its sources are written.
+func saveLeadNames(ctx context.Context, tx *sql.Tx, abn string) error {
+	type name struct{ value, kind string }
+	var names []name
+
+	var registered, trading string
+	if err := tx.QueryRowContext(ctx, "SELECT coalesce(name, ''), coalesce(main_trading_name, '') FROM leads WHERE abn = ?", abn).Scan(&registered, &trading); err != nil {
+		return err
+	}
+	names = append(names, name{registered, nameKindName}, name{trading, nameKindTrading})
+
+	rows, err := tx.QueryContext(ctx, "SELECT coalesce(raw_name, '') FROM lead_sources WHERE abn = ?", abn)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name{raw, nameKindListing})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, n := range names {
+		key := model.NormalizeName(n.value)
+		if key == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO lead_names (abn, name_key, kind) VALUES (?, ?, ?)
+			ON CONFLICT (abn, name_key) DO NOTHING`, abn, key, n.kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillLeadNames records the names of leads saved before lead_names
+// existed.
+func (r *sqlRepo) backfillLeadNames(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT abn FROM leads WHERE abn NOT IN (SELECT abn FROM lead_names)")
+	if err != nil {
+		return err
+	}
+	var abns []string
+	for rows.Next() {
+		var abn string
+		if err := rows.Scan(&abn); err != nil {
+			rows.Close()
+			return err
+		}
+		abns = append(abns, abn)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, abn := range abns {
+		if err := saveLeadNames(ctx, tx, abn); err != nil {
+			return err
+		}
+	}
+	if len(abns) > 0 {
+		r.logger.Info("Backfilled lead names", "leads", len(abns))
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Open opens the repository a -db flag names. The URL scheme picks the
+// backend: sqlite:path or sqlite://path for SQLite, and duckdb://path or a
+// plain path for DuckDB. DuckDB needs cgo; without it, opening a DuckDB path
+// fails.
+func Open(dsn string, logger *slog.Logger) (Repository, error) {
+	backend, path, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if backend == "sqlite" {
+		return NewSQLiteRepo(path, logger)
+	}
+	return openDuckDB(path, logger)
+}
+
+// ParseDSN splits a -db flag value into its backend, duckdb or sqlite, and
+// the database file's path.
+func ParseDSN(dsn string) (backend, path string, err error) {
+	scheme, path, ok := strings.Cut(dsn, ":")
+	if !ok || len(scheme) < 2 || strings.Trim(strings.ToLower(scheme), "abcdefghijklmnopqrstuvwxyz0123456789") != "" {
+		// A plain path, or a Windows drive letter
+		return "duckdb", dsn, nil
+	}
+	path = strings.TrimPrefix(path, "//")
+	switch strings.ToLower(scheme) {
+	case "duckdb":
+		return "duckdb", path, nil
+	case "sqlite", "sqlite3":
+		return "sqlite", path, nil
+	}
+	return "", "", fmt.Errorf("unknown database scheme %q in %q (want sqlite: or duckdb:)", scheme, dsn)
+}
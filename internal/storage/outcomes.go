@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+func (r *sqlRepo) RecordOutcome(ctx context.Context, o model.LeadOutcome) error {
+	if o.RecordedAt.IsZero() {
+		o.RecordedAt = time.Now()
+	}
+	_, err := r.db.ExecContext(ctx, "INSERT INTO lead_outcomes (abn, outcome, note, recorded_at) VALUES (?, ?, ?, ?)",
+		o.ABN, string(o.Outcome), o.Note, o.RecordedAt)
+	return err
+}
+
+// ListOutcomes returns every recorded outcome, oldest first.
+func (r *sqlRepo) ListOutcomes(ctx context.Context) ([]model.LeadOutcome, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT abn, outcome, coalesce(note, ''), recorded_at FROM lead_outcomes ORDER BY recorded_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outcomes []model.LeadOutcome
+	for rows.Next() {
+		var o model.LeadOutcome
+		var outcome string
+		if err := rows.Scan(&o.ABN, &outcome, &o.Note, &o.RecordedAt); err != nil {
+			return nil, err
+		}
+		o.Outcome = model.Outcome(outcome)
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, rows.Err()
+}
+
+// SaveRankModel stores a trained ranking model, serialized by the caller.
+func (r *sqlRepo) SaveRankModel(ctx context.Context, id string, trainedAt time.Time, data []byte) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO rank_models (id, trained_at, model) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET trained_at = EXCLUDED.trained_at, model = EXCLUDED.model",
+		id, trainedAt, string(data))
+	return err
+}
+
+// LatestRankModel returns the most recently trained model, or nil if none.
+func (r *sqlRepo) LatestRankModel(ctx context.Context) ([]byte, error) {
+	var data string
+	err := r.db.QueryRowContext(ctx, "SELECT model FROM rank_models ORDER BY trained_at DESC LIMIT 1").Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(data), nil
+}
+
+// SetRankScores replaces every lead's rank score with scores, keyed by ABN.
+// Leads not in scores (e.g. already reviewed) are cleared.
+func (r *sqlRepo) SetRankScores(ctx context.Context, modelID string, scores map[string]float64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE leads SET rank_score = NULL, rank_model = NULL"); err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, "UPDATE leads SET rank_score = ?, rank_model = ? WHERE abn = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for abn, score := range scores {
+		if _, err := stmt.ExecContext(ctx, score, modelID, abn); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,364 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// Sort orders query results.
+type Sort string
+
+const (
+	SortScore      Sort = "score"       // Highest score first, then oldest
+	SortAge        Sort = "age"         // Oldest registration first
+	SortName       Sort = "name"        // Alphabetical
+	SortUpdated    Sort = "updated"     // Most recently saved first
+	SortNextAction Sort = "next-action" // Soonest next action first
+)
+
+func ParseSort(raw string) (Sort, error) {
+	switch s := Sort(strings.ToLower(strings.TrimSpace(raw))); s {
+	case "":
+		return SortScore, nil
+	case SortScore, SortAge, SortName, SortUpdated, SortNextAction:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown sort %q (want score, age, name, updated or next-action)", raw)
+}
+
+// LeadQuery selects leads. Every field is optional and they combine with AND;
+// lists match any of their values. Everything compiles to placeholders, never
+// to SQL text.
+type LeadQuery struct {
+	Name         string   // Exact, case-insensitive
+	NameContains string   // Case-insensitive substring
+	ABNs         []string // Any of these ABNs
+	States       []string
+	Postcodes    []model.PostcodeRange
+	LGAs         []string
+	Regions      []string
+	Radius       *model.Radius // Also adds a distance_km column to exports
+	Sources      []string      // Stored source names, e.g. from source.ResolveNames
+	Category     string        // Exact, case-insensitive
+	// Whole years since registration; 0 is open. MinAge = MaxAge = 20 is
+	// leads in their 21st year.
+	MinAge       int
+	MaxAge       int
+	EntityTypes  []model.EntityTypeCode
+	Private      *bool // Private company or partnership, and not a charity or NFP
+	GST          *bool
+	Current      *bool // Entity is current in the ABR
+	Rejected     *bool // The pipeline last failed the lead on some criterion
+	UpdatedSince time.Time
+	Website      model.WebsiteFilter
+	// ANZSIC division, subdivision or class codes, e.g. C, 22, 2221
+	Industries []string
+	// Latest ATO-reported total income bounds in dollars; 0 is open. Leads
+	// the ATO doesn't report on pass MaxIncome but not MinIncome.
+	MinIncome int64
+	MaxIncome int64
+	// Deal pipeline: any of these statuses, any of these owners
+	// (case-insensitive), and a next action due on or before DueBy
+	DealStatuses []model.DealStatus
+	Owners       []string
+	DueBy        time.Time
+	// Any of these tags, normalised as model.NormalizeTag does; on any of
+	// these lists, by exact name
+	Tags  []string
+	Lists []string
+
+	Sort  Sort
+	Limit int // 0 is no limit
+}
+
+// Bool returns a pointer to v, for LeadQuery's tri-state fields.
+func Bool(v bool) *bool {
+	return &v
+}
+
+// leadsFrom is the FROM clause every query compiles against, so filters can
+// use tax data.
+const leadsFrom = "leads LEFT JOIN latest_tax_transparency tax ON tax.abn = leads.abn"
+
+// postcodeNumber is the postcode as an integer, NULL if it isn't all digits.
+// It spells out what DuckDB's TRY_CAST does, which SQLite lacks.
+const postcodeNumber = `CASE WHEN trim(leads.postcode) <> '' AND trim(trim(leads.postcode), '0123456789') = ''
+	THEN CAST(trim(leads.postcode) AS INTEGER) END`
+
+// IsZero reports whether the query has no filters, and so matches every lead.
+func (q LeadQuery) IsZero() bool {
+	cond, _ := q.where(time.Now())
+	return cond == "TRUE"
+}
+
+// where compiles the filters to a condition over leadsFrom and its args.
+func (q LeadQuery) where(now time.Time) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, condArgs ...interface{}) {
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+	in := func(expr string, values []string, normalize func(string) string) {
+		if len(values) == 0 {
+			return
+		}
+		placeholders := make([]string, len(values))
+		vals := make([]interface{}, len(values))
+		for i, v := range values {
+			placeholders[i] = "?"
+			vals[i] = normalize(strings.TrimSpace(v))
+		}
+		add(expr+" IN ("+strings.Join(placeholders, ", ")+")", vals...)
+	}
+
+	if q.Name != "" {
+		add("lower(leads.name) = ?", strings.ToLower(q.Name))
+	}
+	if q.NameContains != "" {
+		add(`lower(leads.name) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(q.NameContains))+"%")
+	}
+	in("leads.abn", q.ABNs, func(s string) string { return strings.ReplaceAll(s, " ", "") })
+	in("upper(leads.state)", q.States, strings.ToUpper)
+
+	if len(q.Postcodes) > 0 {
+		var pcConds []string
+		for _, pc := range q.Postcodes {
+			pcConds = append(pcConds, postcodeNumber+" BETWEEN ? AND ?")
+			args = append(args, pc.Min, pc.Max)
+		}
+		conds = append(conds, "("+strings.Join(pcConds, " OR ")+")")
+	}
+
+	if len(q.LGAs) > 0 || len(q.Regions) > 0 {
+		var areaConds []string
+		for _, lga := range q.LGAs {
+			areaConds = append(areaConds, "lower(leads.lga) = ?")
+			args = append(args, strings.ToLower(lga))
+		}
+		for _, region := range q.Regions {
+			areaConds = append(areaConds, "lower(leads.region) = ?")
+			args = append(args, strings.ToLower(region))
+		}
+		conds = append(conds, "("+strings.Join(areaConds, " OR ")+")")
+	}
+
+	if q.Radius != nil {
+		distance, distanceArgs := DistanceSQL(q.Radius.Center)
+		add(distance+" <= ?", append(distanceArgs, q.Radius.Km)...)
+	}
+
+	if len(q.Sources) > 0 {
+		cond, srcArgs := SourcesSQL(q.Sources)
+		add(cond, srcArgs...)
+	}
+	if q.Category != "" {
+		add("lower(leads.category) = ?", strings.ToLower(q.Category))
+	}
+
+	if q.MinAge > 0 {
+		add("leads.registration_date <= ?", now.AddDate(-q.MinAge, 0, 0))
+	}
+	if q.MaxAge > 0 {
+		add("leads.registration_date > ?", now.AddDate(-q.MaxAge-1, 0, 0))
+	}
+
+	if len(q.EntityTypes) > 0 {
+		cond, codeArgs := entityTypeIn(q.EntityTypes)
+		add(cond, codeArgs...)
+	}
+	if q.Private != nil {
+		// Anything the entity type table doesn't class as private, and charities
+		cond, codeArgs := privateEntitySQL()
+		if !*q.Private {
+			cond = "NOT " + cond
+		}
+		add(cond, codeArgs...)
+	}
+	if q.GST != nil {
+		// As the pipeline tests it, registration needs an effective date.
+		// SaveLead stores an unknown one as NULL
+		add("(coalesce(leads.gst_registered, FALSE) AND leads.gst_effective_from IS NOT NULL) = ?", *q.GST)
+	}
+	if q.Current != nil {
+		add("coalesce(leads.is_current_entity, FALSE) = ?", *q.Current)
+	}
+	if q.Rejected != nil {
+		add("(coalesce(leads.rejected_by, '') <> '') = ?", *q.Rejected)
+	}
+	if !q.UpdatedSince.IsZero() {
+		add("leads.updated_at >= ?", q.UpdatedSince)
+	}
+
+	if q.Website.StaleBefore > 0 {
+		add("leads.web_copyright_year > 0 AND leads.web_copyright_year < ?", q.Website.StaleBefore)
+	}
+	if q.Website.NoHTTPS {
+		add("leads.web_https = FALSE")
+	}
+	in("lower(leads.web_platform)", q.Website.Platforms, strings.ToLower)
+
+	if len(q.Industries) > 0 {
+		var indConds []string
+		for _, code := range q.Industries {
+			indConds = append(indConds, "? IN (leads.anzsic_division, leads.anzsic_subdivision, leads.anzsic_class)")
+			args = append(args, strings.ToUpper(code))
+		}
+		conds = append(conds, "("+strings.Join(indConds, " OR ")+")")
+	}
+
+	if q.MinIncome > 0 {
+		add("tax.total_income >= ?", q.MinIncome)
+	}
+	if q.MaxIncome > 0 {
+		// Leads the ATO doesn't report on pass
+		add("(tax.total_income IS NULL OR tax.total_income <= ?)", q.MaxIncome)
+	}
+
+	if len(q.DealStatuses) > 0 {
+		statuses := make([]string, len(q.DealStatuses))
+		for i, st := range q.DealStatuses {
+			statuses[i] = string(st)
+		}
+		in("coalesce(leads.deal_status, 'new')", statuses, strings.ToLower)
+	}
+	in("lower(leads.deal_owner)", q.Owners, strings.ToLower)
+	if !q.DueBy.IsZero() {
+		add("leads.next_action_at <= ?", q.DueBy)
+	}
+	if len(q.Tags) > 0 {
+		tags := make([]string, len(q.Tags))
+		for i, t := range q.Tags {
+			tags[i] = model.NormalizeTag(t)
+		}
+		cond, tagArgs := inList(tags)
+		add("leads.abn IN (SELECT abn FROM lead_tags WHERE tag IN "+cond+")", tagArgs...)
+	}
+	if len(q.Lists) > 0 {
+		cond, listArgs := inList(q.Lists)
+		add("leads.abn IN (SELECT abn FROM lead_list_members WHERE list IN "+cond+")", listArgs...)
+	}
+
+	if len(conds) == 0 {
+		return "TRUE", nil
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// orderBy compiles Sort and Limit.
+func (q LeadQuery) orderBy() string {
+	var order string
+	switch q.Sort {
+	case SortAge:
+		order = "leads.registration_date ASC NULLS LAST, leads.abn"
+	case SortName:
+		order = "lower(leads.name), leads.abn"
+	case SortUpdated:
+		order = "leads.updated_at DESC NULLS LAST, leads.abn"
+	case SortNextAction:
+		order = "leads.next_action_at ASC NULLS LAST, leads.score DESC NULLS LAST, leads.abn"
+	default:
+		order = "leads.score DESC NULLS LAST, leads.registration_date ASC, leads.abn"
+	}
+	if q.Limit > 0 {
+		return fmt.Sprintf("ORDER BY %s LIMIT %d", order, q.Limit)
+	}
+	return "ORDER BY " + order
+}
+
+// String describes the query's filters, e.g. to confirm a delete.
+func (q LeadQuery) String() string {
+	var parts []string
+	add := func(name string, v interface{}) {
+		parts = append(parts, fmt.Sprintf("%s=%v", name, v))
+	}
+	if q.Name != "" {
+		add("name", q.Name)
+	}
+	if q.NameContains != "" {
+		add("name~", q.NameContains)
+	}
+	if len(q.ABNs) > 0 {
+		add("abn", q.ABNs)
+	}
+	if len(q.States) > 0 {
+		add("states", q.States)
+	}
+	if len(q.Postcodes) > 0 {
+		add("postcodes", q.Postcodes)
+	}
+	if len(q.LGAs) > 0 {
+		add("lgas", q.LGAs)
+	}
+	if len(q.Regions) > 0 {
+		add("regions", q.Regions)
+	}
+	if q.Radius != nil {
+		add("radius_km", q.Radius.Km)
+	}
+	if len(q.Sources) > 0 {
+		add("sources", q.Sources)
+	}
+	if q.Category != "" {
+		add("category", q.Category)
+	}
+	if q.MinAge > 0 {
+		add("min_age", q.MinAge)
+	}
+	if q.MaxAge > 0 {
+		add("max_age", q.MaxAge)
+	}
+	if len(q.EntityTypes) > 0 {
+		add("entity_types", q.EntityTypes)
+	}
+	if q.Private != nil {
+		add("private", *q.Private)
+	}
+	if q.GST != nil {
+		add("gst", *q.GST)
+	}
+	if q.Current != nil {
+		add("current", *q.Current)
+	}
+	if q.Rejected != nil {
+		add("rejected", *q.Rejected)
+	}
+	if !q.UpdatedSince.IsZero() {
+		add("updated_since", q.UpdatedSince.Format("2006-01-02"))
+	}
+	if !q.Website.IsZero() {
+		add("website", fmt.Sprintf("%+v", q.Website))
+	}
+	if len(q.Industries) > 0 {
+		add("industries", q.Industries)
+	}
+	if q.MinIncome > 0 {
+		add("min_income", q.MinIncome)
+	}
+	if q.MaxIncome > 0 {
+		add("max_income", q.MaxIncome)
+	}
+	if len(q.DealStatuses) > 0 {
+		add("deal_statuses", q.DealStatuses)
+	}
+	if len(q.Owners) > 0 {
+		add("owners", q.Owners)
+	}
+	if !q.DueBy.IsZero() {
+		add("due_by", q.DueBy.Format("2006-01-02"))
+	}
+	if len(q.Tags) > 0 {
+		add("tags", q.Tags)
+	}
+	if len(q.Lists) > 0 {
+		add("lists", q.Lists)
+	}
+	return strings.Join(parts, " ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
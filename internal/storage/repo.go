@@ -0,0 +1,550 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/anzsic"
+	"github.com/shanehull/sourcerer/internal/geo"
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// sqlRepo implements Repository over database/sql. The SQL is kept to what
+// DuckDB and SQLite share; DuckDBRepo and SQLiteRepo add the rest.
+type sqlRepo struct {
+	db      *sql.DB
+	dialect dialect
+	logger  *slog.Logger
+	runID   string // Tags lead_history rows, see SetRunID
+}
+
+// dialect is what differs between the databases leads can be stored in.
+type dialect struct {
+	name string
+	// migrationSQL adapts a migration, written for DuckDB, to the database
+	migrationSQL func(string) string
+}
+
+// Init brings the schema up to date, see Migrate.
+func (r *sqlRepo) Init(ctx context.Context) error {
+	_, err := r.Migrate(ctx)
+	return err
+}
+
+// backfillEntityTypeCodes derives entity_type_code from the description for
+// leads saved before the code was captured.
+func (r *sqlRepo) backfillEntityTypeCodes(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, "SELECT DISTINCT entity_type FROM leads WHERE entity_type_code IS NULL AND entity_type IS NOT NULL")
+	if err != nil {
+		return err
+	}
+	var descriptions []string
+	for rows.Next() {
+		var d string
+		if err := rows.Scan(&d); err != nil {
+			rows.Close()
+			return err
+		}
+		descriptions = append(descriptions, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range descriptions {
+		code := model.EntityTypeCodeFor(d)
+		if code == "" {
+			r.logger.Warn("Unknown entity type, leaving code empty", "entity_type", d)
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE leads SET entity_type_code = ? WHERE entity_type_code IS NULL AND entity_type = ?", string(code), d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLeadByName returns the lead with exactly this name, ignoring case, or
+// nil. LookupLead is more forgiving.
+func (r *sqlRepo) GetLeadByName(ctx context.Context, name string) (*model.Lead, error) {
+	l, err := scanLead(r.db.QueryRowContext(ctx, "SELECT "+leadColumns+" FROM leads WHERE lower(name) = ? ORDER BY leads.abn LIMIT 1", strings.ToLower(name)))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (r *sqlRepo) GetCharity(ctx context.Context, abn string) (*model.Charity, error) {
+	query := `SELECT abn, coalesce(legal_name, ''), coalesce(size, ''), coalesce(responsible_persons, 0), registration_date, coalesce(website, '')
+	          FROM charities WHERE abn = ?`
+	var c model.Charity
+	var registered sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, abn).Scan(&c.ABN, &c.LegalName, &c.Size, &c.ResponsiblePersons, &registered, &c.Website)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.RegistrationDate = registered.Time
+	return &c, nil
+}
+
+// ImportCharities replaces the ACNC register with charities and re-flags
+// stored leads against it.
+func (r *sqlRepo) ImportCharities(ctx context.Context, charities []model.Charity) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM charities"); err != nil {
+		return 0, err
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO charities (abn, legal_name, size, responsible_persons, registration_date, website, imported_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (abn) DO NOTHING`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, c := range charities {
+		var registered interface{}
+		if !c.RegistrationDate.IsZero() {
+			registered = c.RegistrationDate
+		}
+		if _, err := stmt.ExecContext(ctx, c.ABN, c.LegalName, c.Size, c.ResponsiblePersons, registered, c.Website, now); err != nil {
+			return 0, fmt.Errorf("insert charity %s: %w", c.ABN, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE leads SET is_charity = FALSE, charity_size = NULL, charity_responsible_persons = NULL"); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE leads SET is_charity = TRUE, charity_size = c.size, charity_responsible_persons = c.responsible_persons
+		FROM charities c WHERE leads.abn = c.abn`); err != nil {
+		return 0, err
+	}
+
+	var flagged int
+	if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM leads WHERE is_charity").Scan(&flagged); err != nil {
+		return 0, err
+	}
+	return flagged, tx.Commit()
+}
+
+// ImportTaxRecords replaces one income year of the ATO tax transparency data.
+func (r *sqlRepo) ImportTaxRecords(ctx context.Context, incomeYear int, records []model.TaxRecord) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tax_transparency WHERE income_year = ?", incomeYear); err != nil {
+		return 0, err
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO tax_transparency (abn, income_year, name, total_income, taxable_income, tax_payable, imported_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (abn, income_year) DO NOTHING`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, t := range records {
+		if _, err := stmt.ExecContext(ctx, t.ABN, incomeYear, t.Name, t.TotalIncome, t.TaxableIncome, t.TaxPayable, now); err != nil {
+			return 0, fmt.Errorf("insert tax record %s: %w", t.ABN, err)
+		}
+	}
+
+	var matched int
+	if err := tx.QueryRowContext(ctx, "SELECT count(*) FROM leads WHERE abn IN (SELECT abn FROM tax_transparency WHERE income_year = ?)", incomeYear).Scan(&matched); err != nil {
+		return 0, err
+	}
+	return matched, tx.Commit()
+}
+
+// GetLatestTaxRecord returns the most recent tax transparency year for an ABN.
+func (r *sqlRepo) GetLatestTaxRecord(ctx context.Context, abn string) (*model.TaxRecord, error) {
+	query := `SELECT abn, income_year, coalesce(name, ''), total_income, taxable_income, tax_payable
+	          FROM tax_transparency WHERE abn = ? ORDER BY income_year DESC LIMIT 1`
+	var t model.TaxRecord
+	var total, taxable, payable sql.NullInt64
+	err := r.db.QueryRowContext(ctx, query, abn).Scan(&t.ABN, &t.IncomeYear, &t.Name, &total, &taxable, &payable)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.TotalIncome = nullInt64Ptr(total)
+	t.TaxableIncome = nullInt64Ptr(taxable)
+	t.TaxPayable = nullInt64Ptr(payable)
+	return &t, nil
+}
+
+func nullInt64Ptr(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+func (r *sqlRepo) SaveLead(ctx context.Context, l model.Lead) (bool, error) {
+	sourceStr := strings.Join(l.Sources, ",")
+	ageYears := l.AgeYears()
+	
+	query := `
+	INSERT INTO leads (abn, name, category, sources, entity_type, entity_status, state, postcode, registration_date, age_years, gst_registered, gst_effective_from, is_current_entity, acn, main_trading_name, phone, email, business_url, found_at_url, lga, region, latitude, longitude, state_mismatch, web_checked_at, web_platform, web_https, web_copyright_year, web_has_store, web_has_careers, web_page_bytes, web_title, web_description, anzsic_division, anzsic_subdivision, anzsic_class, anzsic_title, anzsic_confidence, entity_type_code, is_nfp, is_charity, charity_size, charity_responsible_persons, criteria, rejected_by, enriched_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (abn) DO UPDATE SET
+		name = COALESCE(NULLIF(EXCLUDED.name, ''), leads.name),
+		category = COALESCE(NULLIF(EXCLUDED.category, ''), leads.category),
+		state = COALESCE(NULLIF(EXCLUDED.state, ''), leads.state),
+		postcode = COALESCE(NULLIF(EXCLUDED.postcode, ''), leads.postcode),
+		lga = EXCLUDED.lga,
+		region = EXCLUDED.region,
+		latitude = EXCLUDED.latitude,
+		longitude = EXCLUDED.longitude,
+		state_mismatch = EXCLUDED.state_mismatch,
+		web_checked_at = COALESCE(EXCLUDED.web_checked_at, leads.web_checked_at),
+		web_platform = COALESCE(EXCLUDED.web_platform, leads.web_platform),
+		web_https = COALESCE(EXCLUDED.web_https, leads.web_https),
+		web_copyright_year = COALESCE(EXCLUDED.web_copyright_year, leads.web_copyright_year),
+		web_has_store = COALESCE(EXCLUDED.web_has_store, leads.web_has_store),
+		web_has_careers = COALESCE(EXCLUDED.web_has_careers, leads.web_has_careers),
+		web_page_bytes = COALESCE(EXCLUDED.web_page_bytes, leads.web_page_bytes),
+		web_title = COALESCE(EXCLUDED.web_title, leads.web_title),
+		web_description = COALESCE(EXCLUDED.web_description, leads.web_description),
+		anzsic_division = COALESCE(EXCLUDED.anzsic_division, leads.anzsic_division),
+		anzsic_subdivision = CASE WHEN EXCLUDED.anzsic_division IS NULL THEN leads.anzsic_subdivision ELSE EXCLUDED.anzsic_subdivision END,
+		anzsic_class = CASE WHEN EXCLUDED.anzsic_division IS NULL THEN leads.anzsic_class ELSE EXCLUDED.anzsic_class END,
+		anzsic_title = COALESCE(EXCLUDED.anzsic_title, leads.anzsic_title),
+		anzsic_confidence = COALESCE(EXCLUDED.anzsic_confidence, leads.anzsic_confidence),
+		entity_type = COALESCE(NULLIF(EXCLUDED.entity_type, ''), leads.entity_type),
+		entity_status = COALESCE(NULLIF(EXCLUDED.entity_status, ''), leads.entity_status),
+		entity_type_code = COALESCE(EXCLUDED.entity_type_code, leads.entity_type_code),
+		is_nfp = EXCLUDED.is_nfp,
+		is_charity = EXCLUDED.is_charity,
+		charity_size = EXCLUDED.charity_size,
+		charity_responsible_persons = EXCLUDED.charity_responsible_persons,
+		age_years = EXCLUDED.age_years,
+		gst_registered = EXCLUDED.gst_registered,
+		gst_effective_from = EXCLUDED.gst_effective_from,
+		is_current_entity = EXCLUDED.is_current_entity,
+		acn = EXCLUDED.acn,
+		main_trading_name = COALESCE(NULLIF(EXCLUDED.main_trading_name, ''), leads.main_trading_name),
+		phone = COALESCE(NULLIF(EXCLUDED.phone, ''), leads.phone),
+		email = COALESCE(NULLIF(EXCLUDED.email, ''), leads.email),
+		business_url = COALESCE(NULLIF(EXCLUDED.business_url, ''), leads.business_url),
+		criteria = COALESCE(EXCLUDED.criteria, leads.criteria),
+		rejected_by = CASE WHEN EXCLUDED.criteria IS NULL THEN leads.rejected_by ELSE EXCLUDED.rejected_by END,
+		enriched_at = COALESCE(EXCLUDED.enriched_at, leads.enriched_at),
+		updated_at = EXCLUDED.updated_at;`
+
+	args := []interface{}{l.ABN, l.Name, l.Category, sourceStr, l.EntityType, l.EntityStatus, l.State, l.Postcode, l.RegistrationDate, ageYears, l.IsGSTRegistered, nullTime(l.GSTEffectiveFrom), l.IsCurrentEntity, l.ACN, l.MainTradingName, l.Phone, l.Email, l.BusinessURL, l.FoundAtURL, l.LGA, l.Region, nullFloat(l.Latitude), nullFloat(l.Longitude), l.StateMismatch}
+	args = append(args, websiteArgs(l.Website)...)
+	args = append(args, industryArgs(l.Industry)...)
+	entityCode := l.EntityTypeCode
+	if entityCode == "" {
+		entityCode = model.EntityTypeCodeFor(l.EntityType)
+	}
+	args = append(args, nullString(string(entityCode)))
+	args = append(args, charityArgs(l)...)
+	args = append(args, criteriaArgs(l.Criteria)...)
+	args = append(args, nullTime(l.EnrichedAt))
+	now := time.Now()
+	args = append(args, now)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	before, err := historySnapshot(ctx, tx, l.ABN)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return false, err
+	}
+	if err := saveLeadSources(ctx, tx, l, now); err != nil {
+		return false, err
+	}
+	if err := saveFieldValues(ctx, tx, l); err != nil {
+		return false, err
+	}
+	if err := saveLeadNames(ctx, tx, l.ABN); err != nil {
+		return false, err
+	}
+	after, err := historySnapshot(ctx, tx, l.ABN)
+	if err != nil {
+		return false, err
+	}
+	if err := recordChanges(ctx, tx, l.ABN, r.runID, before, after, now); err != nil {
+		return false, err
+	}
+	return before == nil, tx.Commit()
+}
+
+// ClassifyUnclassified runs classify over stored leads that have no industry
+// yet, e.g. those saved before classification existed, and saves the result.
+// A lead that matches no class is marked as tried, with zero confidence, and
+// left alone until it's saved again.
+func (r *sqlRepo) ClassifyUnclassified(ctx context.Context, classify func(*model.Lead)) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT abn, coalesce(name, ''), coalesce(category, ''), coalesce(main_trading_name, ''), coalesce(web_title, ''), coalesce(web_description, '')
+		FROM leads
+		WHERE anzsic_division IS NULL AND (anzsic_classified_at IS NULL OR anzsic_classified_at < updated_at)`)
+	if err != nil {
+		return 0, err
+	}
+	var leads []model.Lead
+	for rows.Next() {
+		var l model.Lead
+		if err := rows.Scan(&l.ABN, &l.Name, &l.Category, &l.MainTradingName, &l.Website.Title, &l.Website.Description); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		leads = append(leads, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	classified := 0
+	for i := range leads {
+		classify(&leads[i])
+		if leads[i].Industry.IsZero() {
+			if _, err := r.db.ExecContext(ctx, "UPDATE leads SET anzsic_confidence = 0, anzsic_classified_at = ? WHERE abn = ?", now, leads[i].ABN); err != nil {
+				return classified, err
+			}
+			continue
+		}
+		args := append(industryArgs(leads[i].Industry), now, leads[i].ABN)
+		if _, err := r.db.ExecContext(ctx, `
+			UPDATE leads SET anzsic_division = ?, anzsic_subdivision = ?, anzsic_class = ?, anzsic_title = ?, anzsic_confidence = ?, anzsic_classified_at = ?
+			WHERE abn = ?`, args...); err != nil {
+			return classified, err
+		}
+		classified++
+	}
+	return classified, nil
+}
+
+// InspectUninspected runs inspect over the stored leads q selects, its
+// website filter aside, whose sites have never been inspected, and saves the
+// signals it reads. The pipeline only inspects leads passing every other
+// criterion, so these are leads a relaxed criterion has let in. A site
+// inspect can't read is tried again next time. It returns how many it
+// inspected.
+func (r *sqlRepo) InspectUninspected(ctx context.Context, q LeadQuery, inspect func(*model.Lead)) (int, error) {
+	q.Website = model.WebsiteFilter{}
+	where, args := q.where(time.Now())
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT leads.abn, coalesce(leads.name, ''), leads.business_url FROM `+leadsFrom+`
+		WHERE (`+where+`) AND coalesce(leads.business_url, '') <> '' AND leads.web_checked_at IS NULL`, args...)
+	if err != nil {
+		return 0, err
+	}
+	var leads []model.Lead
+	for rows.Next() {
+		var l model.Lead
+		if err := rows.Scan(&l.ABN, &l.Name, &l.BusinessURL); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		leads = append(leads, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	inspected := 0
+	for i := range leads {
+		inspect(&leads[i])
+		if leads[i].Website.CheckedAt.IsZero() {
+			continue
+		}
+		args := append(websiteArgs(leads[i].Website), leads[i].ABN)
+		if _, err := r.db.ExecContext(ctx, `
+			UPDATE leads SET web_checked_at = ?, web_platform = ?, web_https = ?, web_copyright_year = ?, web_has_store = ?, web_has_careers = ?, web_page_bytes = ?, web_title = ?, web_description = ?
+			WHERE abn = ?`, args...); err != nil {
+			return inspected, err
+		}
+		inspected++
+	}
+	return inspected, nil
+}
+
+// GetLead returns the lead with this ABN, or nil if there isn't one.
+func (r *sqlRepo) GetLead(ctx context.Context, abn string) (*model.Lead, error) {
+	l, err := scanLead(r.db.QueryRowContext(ctx, "SELECT "+leadColumns+" FROM leads WHERE abn = ?", abn))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// ListLeads returns every stored lead.
+func (r *sqlRepo) ListLeads(ctx context.Context) ([]model.Lead, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+leadColumns+" FROM leads ORDER BY leads.abn")
+	if err != nil {
+		return nil, err
+	}
+	return scanLeads(rows)
+}
+
+// Rescore recomputes the score of every lead scored under other weights,
+// changed since it was scored, or last scored before today, as the age rules
+// move with the calendar. It returns how many it updated.
+func (r *sqlRepo) Rescore(ctx context.Context, weights string, score func(*model.Lead) model.Score) (int, error) {
+	y, m, d := time.Now().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	rows, err := r.db.QueryContext(ctx, "SELECT "+leadColumns+`
+		FROM leads
+		WHERE score_weights IS NULL OR score_weights <> ? OR scored_at IS NULL OR scored_at < updated_at OR scored_at < ?`, weights, today)
+	if err != nil {
+		return 0, err
+	}
+	leads, err := scanLeads(rows)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	for i, l := range leads {
+		sc := score(&l)
+		breakdown, err := json.Marshal(sc.Breakdown)
+		if err != nil {
+			return i, err
+		}
+		if _, err := r.db.ExecContext(ctx, `
+			UPDATE leads SET score = ?, score_breakdown = ?, score_weights = ?, scored_at = ?
+			WHERE abn = ?`, sc.Total, string(breakdown), sc.Weights, now, l.ABN); err != nil {
+			return i, err
+		}
+	}
+	return len(leads), nil
+}
+
+// FindLeads returns the leads q selects, in its order.
+func (r *sqlRepo) FindLeads(ctx context.Context, q LeadQuery) ([]model.Lead, error) {
+	where, args := q.where(time.Now())
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE %s %s", leadColumns, leadsFrom, where, q.orderBy()), args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanLeads(rows)
+}
+
+// CountLeads returns how many leads q selects, ignoring its limit.
+func (r *sqlRepo) CountLeads(ctx context.Context, q LeadQuery) (int, error) {
+	where, args := q.where(time.Now())
+	var count int
+	err := r.db.QueryRowContext(ctx, fmt.Sprintf("SELECT count(*) FROM %s WHERE %s", leadsFrom, where), args...).Scan(&count)
+	return count, err
+}
+
+// DistanceSQL returns a haversine expression for the km between a lead's
+// postcode centroid and p, with its placeholder args. It is NULL for leads
+// without a location.
+func DistanceSQL(p geo.Point) (string, []interface{}) {
+	expr := `(6371 * 2 * asin(sqrt(
+		pow(sin(radians(latitude - ?) / 2), 2) +
+		cos(radians(?)) * cos(radians(latitude)) * pow(sin(radians(longitude - ?) / 2), 2))))`
+	return expr, []interface{}{p.Latitude, p.Latitude, p.Longitude}
+}
+
+// websiteArgs returns the web_* column values, all NULL if the site wasn't
+// inspected so the upsert keeps what we already have.
+func websiteArgs(w model.WebsiteSignals) []interface{} {
+	if w.CheckedAt.IsZero() {
+		return []interface{}{nil, nil, nil, nil, nil, nil, nil, nil, nil}
+	}
+	return []interface{}{w.CheckedAt, w.Platform, w.HTTPS, w.CopyrightYear, w.HasStore, w.HasCareers, w.PageBytes, w.Title, w.Description}
+}
+
+// criteriaArgs returns the criteria and rejected_by column values, both NULL
+// if the lead wasn't tested so the upsert keeps earlier results.
+func criteriaArgs(c model.CriteriaResults) []interface{} {
+	if len(c) == 0 {
+		return []interface{}{nil, nil}
+	}
+	data, _ := json.Marshal(c)
+	return []interface{}{string(data), c.RejectedBy()}
+}
+
+// industryArgs returns the anzsic_* column values, all NULL if the lead wasn't
+// classified so the upsert keeps an earlier classification.
+func industryArgs(c anzsic.Classification) []interface{} {
+	if c.IsZero() {
+		return []interface{}{nil, nil, nil, nil, nil}
+	}
+	return []interface{}{c.Division, nullString(c.Subdivision), nullString(c.Class), c.Title, c.Confidence}
+}
+
+// privateEntitySQL is the SQL form of Lead.IsPrivateEntity.
+func privateEntitySQL() (string, []interface{}) {
+	cond, args := entityTypeIn(model.PrivateEntityTypeCodes())
+	return "(" + cond + " AND NOT coalesce(leads.is_charity, FALSE) AND NOT coalesce(leads.is_nfp, FALSE))", args
+}
+
+func charityArgs(l model.Lead) []interface{} {
+	if l.Charity == nil {
+		return []interface{}{l.IsNFP, false, nil, nil}
+	}
+	return []interface{}{l.IsNFP, true, l.Charity.Size, l.Charity.ResponsiblePersons}
+}
+
+// entityTypeIn returns an "entity_type_code IN (...)" condition and its args.
+// It's FALSE, not NULL, for leads without a code, so it negates safely.
+func entityTypeIn(codes []model.EntityTypeCode) (string, []interface{}) {
+	placeholders := make([]string, len(codes))
+	args := make([]interface{}, len(codes))
+	for i, code := range codes {
+		placeholders[i] = "?"
+		args[i] = string(code)
+	}
+	return "coalesce(leads.entity_type_code, '') IN (" + strings.Join(placeholders, ", ") + ")", args
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullFloat(f float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: f, Valid: f != 0}
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+func (r *sqlRepo) Close() error {
+	return r.db.Close()
+}
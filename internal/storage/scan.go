@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// leadColumns selects a full lead for scanLead. NULLs are coalesced to zero
+// values, except timestamps which scan into sql.NullTime.
+const leadColumns = `leads.abn, coalesce(name, ''), coalesce(category, ''), coalesce(sources, ''),
+	coalesce(entity_type, ''), coalesce(entity_type_code, ''), coalesce(entity_status, ''),
+	coalesce(state, ''), coalesce(postcode, ''), coalesce(lga, ''), coalesce(region, ''),
+	coalesce(latitude, 0), coalesce(longitude, 0), coalesce(state_mismatch, FALSE),
+	registration_date, coalesce(gst_registered, FALSE), gst_effective_from, coalesce(is_current_entity, FALSE),
+	coalesce(acn, ''), coalesce(main_trading_name, ''), coalesce(phone, ''), coalesce(email, ''),
+	coalesce(business_url, ''), coalesce(found_at_url, ''),
+	web_checked_at, coalesce(web_platform, ''), coalesce(web_https, FALSE), coalesce(web_copyright_year, 0),
+	coalesce(web_has_store, FALSE), coalesce(web_has_careers, FALSE), coalesce(web_page_bytes, 0),
+	coalesce(web_title, ''), coalesce(web_description, ''),
+	coalesce(anzsic_division, ''), coalesce(anzsic_subdivision, ''), coalesce(anzsic_class, ''),
+	coalesce(anzsic_title, ''), coalesce(anzsic_confidence, 0),
+	coalesce(is_nfp, FALSE), coalesce(score, 0), coalesce(score_breakdown, ''), coalesce(score_weights, ''),
+	coalesce(criteria, ''), enriched_at, updated_at,
+	coalesce(deal_status, 'new'), coalesce(deal_owner, ''), coalesce(next_action, ''), next_action_at, deal_updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLead(row rowScanner) (model.Lead, error) {
+	var l model.Lead
+	var sources, breakdown, criteria string
+	var registered, gstFrom, webChecked, enriched, updated, nextAction, dealUpdated sql.NullTime
+	err := row.Scan(&l.ABN, &l.Name, &l.Category, &sources,
+		&l.EntityType, &l.EntityTypeCode, &l.EntityStatus,
+		&l.State, &l.Postcode, &l.LGA, &l.Region,
+		&l.Latitude, &l.Longitude, &l.StateMismatch,
+		&registered, &l.IsGSTRegistered, &gstFrom, &l.IsCurrentEntity,
+		&l.ACN, &l.MainTradingName, &l.Phone, &l.Email,
+		&l.BusinessURL, &l.FoundAtURL,
+		&webChecked, &l.Website.Platform, &l.Website.HTTPS, &l.Website.CopyrightYear,
+		&l.Website.HasStore, &l.Website.HasCareers, &l.Website.PageBytes,
+		&l.Website.Title, &l.Website.Description,
+		&l.Industry.Division, &l.Industry.Subdivision, &l.Industry.Class,
+		&l.Industry.Title, &l.Industry.Confidence,
+		&l.IsNFP, &l.Score.Total, &breakdown, &l.Score.Weights,
+		&criteria, &enriched, &updated,
+		&l.Deal.Status, &l.Deal.Owner, &l.Deal.NextAction, &nextAction, &dealUpdated)
+	if err != nil {
+		return l, err
+	}
+
+	l.RegistrationDate = registered.Time
+	l.GSTEffectiveFrom = gstFrom.Time
+	l.Website.CheckedAt = webChecked.Time
+	l.EnrichedAt = enriched.Time
+	l.UpdatedAt = updated.Time
+	l.Deal.NextActionAt = nextAction.Time
+	l.Deal.UpdatedAt = dealUpdated.Time
+	if sources != "" {
+		l.Sources = strings.Split(sources, ",")
+	}
+	if breakdown != "" {
+		_ = json.Unmarshal([]byte(breakdown), &l.Score.Breakdown)
+	}
+	if criteria != "" {
+		_ = json.Unmarshal([]byte(criteria), &l.Criteria)
+	}
+	return l, nil
+}
+
+func scanLeads(rows *sql.Rows) ([]model.Lead, error) {
+	defer rows.Close()
+	var leads []model.Lead
+	for rows.Next() {
+		l, err := scanLead(rows)
+		if err != nil {
+			return nil, err
+		}
+		leads = append(leads, l)
+	}
+	return leads, rows.Err()
+}
@@ -0,0 +1,391 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// leadTables hold a lead's rows, keyed by ABN: leads itself first, then the
+// provenance, notes, tags and list places that go with it. A delete
+// snapshots and removes all of them. lead_history and outcomes are records
+// of the past and outlive the lead, as do export hand-offs, so the next
+// delta can report the lead dropped.
+var leadTables = []string{"leads", "lead_sources", "lead_field_values", "lead_names",
+	"lead_notes", "lead_tags", "lead_list_members"}
+
+// abnBatch is how many ABNs go in one IN list.
+const abnBatch = 500
+
+// DeleteLeads deletes the leads q selects, first snapshotting their rows in
+// a delete batch that UndoDelete can restore. A query without filters is
+// refused rather than deleting everything. The batch is empty, with no ID,
+// if nothing matched. If suppress isn't nil, each deleted ABN is also
+// suppressed with its reason and expiry, as part of the batch so undoing it
+// lifts them.
+func (r *sqlRepo) DeleteLeads(ctx context.Context, q LeadQuery, author string, suppress *model.Suppression) (model.DeleteBatch, error) {
+	batch := model.DeleteBatch{DeletedAt: time.Now(), Author: author, Filters: q.String()}
+	if q.IsZero() {
+		return batch, fmt.Errorf("no filters provided")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return batch, err
+	}
+	defer tx.Rollback()
+
+	// The filters can depend on the provenance being deleted, so settle
+	// which leads match before touching anything
+	where, args := q.where(time.Now())
+	abns, err := queryStrings(ctx, tx, fmt.Sprintf("SELECT leads.abn FROM %s WHERE %s", leadsFrom, where), args...)
+	if err != nil {
+		return batch, err
+	}
+	if len(abns) == 0 {
+		return batch, nil
+	}
+
+	if err := newDeleteBatch(ctx, tx, &batch, abns); err != nil {
+		return batch, err
+	}
+	if err := deleteLeadRows(ctx, tx, abns); err != nil {
+		return batch, err
+	}
+	if suppress != nil {
+		for _, abn := range abns {
+			s := *suppress
+			s.ID, s.ABN, s.NamePattern, s.BatchID = "", abn, "", batch.ID
+			if _, err := addSuppression(ctx, tx, s); err != nil {
+				return batch, err
+			}
+		}
+	}
+	return batch, tx.Commit()
+}
+
+// newDeleteBatch records a delete batch for abns, snapshotting their rows,
+// ahead of the caller removing them. It sets the batch's ID and size.
+func newDeleteBatch(ctx context.Context, tx *sql.Tx, batch *model.DeleteBatch, abns []string) error {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	batch.ID = hex.EncodeToString(b)
+	batch.Leads = len(abns)
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO delete_batches (id, deleted_at, author, filters, leads) VALUES (?, ?, ?, ?, ?)`,
+		batch.ID, batch.DeletedAt, batch.Author, batch.Filters, batch.Leads); err != nil {
+		return err
+	}
+	for start := 0; start < len(abns); start += abnBatch {
+		in, inArgs := inList(abns[start:min(start+abnBatch, len(abns))])
+		for _, table := range leadTables {
+			if err := snapshotRows(ctx, tx, batch.ID, table, in, inArgs); err != nil {
+				return fmt.Errorf("snapshot %s: %w", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteLeadRows deletes abns' rows from every lead table.
+func deleteLeadRows(ctx context.Context, tx *sql.Tx, abns []string) error {
+	for start := 0; start < len(abns); start += abnBatch {
+		in, inArgs := inList(abns[start:min(start+abnBatch, len(abns))])
+		// Provenance first, leaving the leads for last
+		for i := len(leadTables) - 1; i >= 0; i-- {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+leadTables[i]+" WHERE abn IN "+in, inArgs...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotRows copies table's rows for the ABNs in into the batch.
+func snapshotRows(ctx context.Context, tx *sql.Tx, batchID, table, in string, inArgs []interface{}) error {
+	rows, err := tx.QueryContext(ctx, "SELECT * FROM "+table+" WHERE abn IN "+in, inArgs...)
+	if err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return err
+	}
+	type snapshot struct{ abn, data string }
+	var snapshots []snapshot
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			rows.Close()
+			return err
+		}
+		row := make(map[string]interface{}, len(columns))
+		var abn string
+		for i, c := range columns {
+			v := values[i]
+			switch x := v.(type) {
+			case time.Time:
+				v = x.UTC().Format(time.RFC3339Nano)
+			case []byte:
+				v = string(x)
+			}
+			row[c] = v
+			if c == "abn" {
+				abn = fmt.Sprint(v)
+			}
+		}
+		data, err := json.Marshal(row)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		snapshots = append(snapshots, snapshot{abn, string(data)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, s := range snapshots {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO deleted_leads (batch_id, abn, source_table, row_data) VALUES (?, ?, ?, ?)",
+			batchID, s.abn, table, s.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UndoDelete restores the leads a delete batch removed and lifts the
+// suppressions it added. Leads that have been stored again since are left as
+// they are and returned as skipped.
+func (r *sqlRepo) UndoDelete(ctx context.Context, batchID string) (restored int, skipped []string, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	var restoredAt sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT restored_at FROM delete_batches WHERE id = ?", batchID).Scan(&restoredAt)
+	if err == sql.ErrNoRows {
+		return 0, nil, fmt.Errorf("no delete batch %s", batchID)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if restoredAt.Valid {
+		return 0, nil, fmt.Errorf("delete batch %s was already undone at %s", batchID, restoredAt.Time.Local().Format("2006-01-02 15:04"))
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT d.abn, d.source_table, d.row_data, leads.abn IS NOT NULL
+		FROM deleted_leads d LEFT JOIN leads ON leads.abn = d.abn
+		WHERE d.batch_id = ?`, batchID)
+	if err != nil {
+		return 0, nil, err
+	}
+	type snapshot struct{ abn, table, data string }
+	var snapshots []snapshot
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var s snapshot
+		var exists bool
+		if err := rows.Scan(&s.abn, &s.table, &s.data, &exists); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		if exists {
+			existing[s.abn] = true
+			continue
+		}
+		snapshots = append(snapshots, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+
+	types := make(map[string]map[string]string)
+	for _, s := range snapshots {
+		if types[s.table] == nil {
+			if types[s.table], err = columnTypes(ctx, tx, s.table); err != nil {
+				return 0, nil, err
+			}
+		}
+		if err := restoreRow(ctx, tx, s.table, s.data, types[s.table]); err != nil {
+			return 0, nil, fmt.Errorf("restore %s row for %s: %w", s.table, s.abn, err)
+		}
+		if s.table == "leads" {
+			restored++
+		}
+	}
+	for abn := range existing {
+		skipped = append(skipped, abn)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM suppressions WHERE batch_id = ?", batchID); err != nil {
+		return 0, nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE delete_batches SET restored_at = ? WHERE id = ?", time.Now(), batchID); err != nil {
+		return 0, nil, err
+	}
+	return restored, skipped, tx.Commit()
+}
+
+// columnTypes returns the database type of each of table's columns.
+func columnTypes(ctx context.Context, tx *sql.Tx, table string) (map[string]string, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT * FROM "+table+" LIMIT 0")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cts, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(cts))
+	for _, ct := range cts {
+		types[ct.Name()] = strings.ToUpper(ct.DatabaseTypeName())
+	}
+	return types, rows.Err()
+}
+
+// restoreRow inserts a snapshotted row, converting its JSON values back to
+// the column types. Columns the table has since lost are dropped, and ones
+// it has gained are left NULL.
+func restoreRow(ctx context.Context, tx *sql.Tx, table, data string, types map[string]string) error {
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	var row map[string]interface{}
+	if err := dec.Decode(&row); err != nil {
+		return err
+	}
+
+	var columns, placeholders []string
+	var args []interface{}
+	for name, v := range row {
+		typ, ok := types[name]
+		if !ok {
+			continue
+		}
+		switch x := v.(type) {
+		case string:
+			if strings.Contains(typ, "TIMESTAMP") || typ == "DATE" {
+				t, err := time.Parse(time.RFC3339Nano, x)
+				if err != nil {
+					return fmt.Errorf("column %s: %w", name, err)
+				}
+				v = t
+			}
+		case json.Number:
+			if strings.Contains(typ, "INT") || typ == "BOOLEAN" {
+				n, err := x.Int64()
+				if err != nil {
+					return fmt.Errorf("column %s: %w", name, err)
+				}
+				v = n
+			} else {
+				f, err := x.Float64()
+				if err != nil {
+					return fmt.Errorf("column %s: %w", name, err)
+				}
+				v = f
+			}
+		}
+		columns = append(columns, name)
+		placeholders = append(placeholders, "?")
+		args = append(args, v)
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING",
+		table, strings.Join(columns, ", "), strings.Join(placeholders, ", ")), args...)
+	return err
+}
+
+// ListDeleteBatches returns the delete batches, newest first.
+func (r *sqlRepo) ListDeleteBatches(ctx context.Context) ([]model.DeleteBatch, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, deleted_at, coalesce(author, ''), coalesce(filters, ''), leads, restored_at
+		FROM delete_batches ORDER BY deleted_at DESC, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var batches []model.DeleteBatch
+	for rows.Next() {
+		var b model.DeleteBatch
+		var restoredAt sql.NullTime
+		if err := rows.Scan(&b.ID, &b.DeletedAt, &b.Author, &b.Filters, &b.Leads, &restoredAt); err != nil {
+			return nil, err
+		}
+		b.RestoredAt = restoredAt.Time
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+// PurgeDeleteBatches drops the snapshots of deletes made before cutoff,
+// after which they can't be undone. It returns how many were dropped.
+func (r *sqlRepo) PurgeDeleteBatches(ctx context.Context, cutoff time.Time) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM deleted_leads WHERE batch_id IN (SELECT id FROM delete_batches WHERE deleted_at < ?)", cutoff); err != nil {
+		return 0, err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM delete_batches WHERE deleted_at < ?", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), tx.Commit()
+}
+
+// querier is a *sql.DB or a *sql.Tx, for reads that are sometimes part of a
+// larger transaction.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryStrings returns the single string column query selects.
+func queryStrings(ctx context.Context, db querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// inList returns a parenthesised placeholder list for values, with its args.
+func inList(values []string) (string, []interface{}) {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return "(" + strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ") + ")", args
+}
@@ -0,0 +1,174 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// SourcesSQL returns a condition matching leads listed by any of the named
+// sources, with its placeholder args. Names match exactly, so RTO doesn't
+// match a lead only listed by some other source with RTO in its name.
+func SourcesSQL(names []string) (string, []interface{}) {
+	placeholders := make([]string, len(names))
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		placeholders[i] = "?"
+		args[i] = name
+	}
+	return "leads.abn IN (SELECT abn FROM lead_sources WHERE source IN (" + strings.Join(placeholders, ", ") + "))", args
+}
+
+// GetLeadSources returns the sources that have listed a lead, first seen first.
+func (r *sqlRepo) GetLeadSources(ctx context.Context, abn string) ([]model.SourceRecord, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT source, coalesce(found_at_url, ''), coalesce(category, ''), coalesce(raw_name, ''), first_seen, last_seen
+		FROM lead_sources WHERE abn = ? ORDER BY first_seen, source`, abn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []model.SourceRecord
+	for rows.Next() {
+		var rec model.SourceRecord
+		var first, last sql.NullTime
+		if err := rows.Scan(&rec.Source, &rec.FoundAtURL, &rec.Category, &rec.RawName, &first, &last); err != nil {
+			return nil, err
+		}
+		rec.FirstSeen = first.Time
+		rec.LastSeen = last.Time
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// saveLeadSources upserts the lead's provenance and rebuilds leads.sources,
+// which is kept only for display, from it.
+func saveLeadSources(ctx context.Context, tx *sql.Tx, l model.Lead, now time.Time) error {
+	records := l.Provenance
+	if len(records) == 0 {
+		records = provenanceFromSources(l)
+	}
+
+	for _, rec := range records {
+		if rec.Source == "" {
+			continue
+		}
+		first, last := rec.FirstSeen, rec.LastSeen
+		if first.IsZero() {
+			first = now
+		}
+		if last.IsZero() {
+			last = now
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO lead_sources (abn, source, found_at_url, category, raw_name, first_seen, last_seen)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (abn, source) DO UPDATE SET
+				found_at_url = COALESCE(NULLIF(EXCLUDED.found_at_url, ''), lead_sources.found_at_url),
+				category = COALESCE(NULLIF(EXCLUDED.category, ''), lead_sources.category),
+				raw_name = COALESCE(NULLIF(EXCLUDED.raw_name, ''), lead_sources.raw_name),
+				last_seen = EXCLUDED.last_seen`,
+			l.ABN, rec.Source, rec.FoundAtURL, rec.Category, rec.RawName, first, last); err != nil {
+			return err
+		}
+	}
+
+	return rebuildLeadSources(ctx, tx, l.ABN)
+}
+
+// rebuildLeadSources rebuilds leads.sources from the lead's provenance.
+func rebuildLeadSources(ctx context.Context, tx *sql.Tx, abn string) error {
+	rows, err := tx.QueryContext(ctx, "SELECT source FROM lead_sources WHERE abn = ? ORDER BY first_seen, source", abn)
+	if err != nil {
+		return err
+	}
+	var sources []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			rows.Close()
+			return err
+		}
+		sources = append(sources, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE leads SET sources = ? WHERE abn = ?", strings.Join(sources, ","), abn)
+	return err
+}
+
+// provenanceFromSources is the provenance of a lead saved without one. Only
+// the first source can be credited with the URL we found it at.
+func provenanceFromSources(l model.Lead) []model.SourceRecord {
+	var records []model.SourceRecord
+	for i, s := range l.Sources {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		rec := model.SourceRecord{Source: s, Category: l.Category, RawName: l.Name}
+		if i == 0 {
+			rec.FoundAtURL = l.FoundAtURL
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// backfillLeadSources splits leads.sources into lead_sources for leads saved
+// before provenance was tracked. We don't know when each source first listed
+// them, so both dates are the lead's last update.
+func (r *sqlRepo) backfillLeadSources(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT abn, sources, coalesce(found_at_url, ''), coalesce(category, ''), coalesce(name, ''), updated_at
+		FROM leads
+		WHERE coalesce(sources, '') <> '' AND abn NOT IN (SELECT abn FROM lead_sources)`)
+	if err != nil {
+		return err
+	}
+	var leads []model.Lead
+	var seen []time.Time
+	for rows.Next() {
+		var l model.Lead
+		var sources string
+		var updated sql.NullTime
+		if err := rows.Scan(&l.ABN, &sources, &l.FoundAtURL, &l.Category, &l.Name, &updated); err != nil {
+			rows.Close()
+			return err
+		}
+		l.Sources = strings.Split(sources, ",")
+		leads = append(leads, l)
+		seen = append(seen, updated.Time)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	now := time.Now()
+	for i, l := range leads {
+		at := seen[i]
+		if at.IsZero() {
+			at = now
+		}
+		l.Provenance = provenanceFromSources(l)
+		for j := range l.Provenance {
+			l.Provenance[j].FirstSeen = at
+			l.Provenance[j].LastSeen = at
+		}
+		if err := saveLeadSources(ctx, tx, l, now); err != nil {
+			return err
+		}
+	}
+	if len(leads) > 0 {
+		r.logger.Info("Backfilled lead sources", "leads", len(leads))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// SQLiteRepo stores leads in a SQLite file. Unlike DuckDB it needs no cgo,
+// so it suits cross-compiled and small deploys.
+type SQLiteRepo struct {
+	*sqlRepo
+}
+
+func NewSQLiteRepo(path string, logger *slog.Logger) (*SQLiteRepo, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+	db := sql.OpenDB(sqliteConnector{dsn: dsn})
+	// One writer at a time, and one connection is the only way an in-memory
+	// database is shared
+	db.SetMaxOpenConns(1)
+	return &SQLiteRepo{&sqlRepo{db: db, dialect: sqliteDialect, logger: logger}}, nil
+}
+
+var sqliteDialect = dialect{
+	name: "sqlite",
+	// SQLite has no IF NOT EXISTS on columns. Each migration's schema
+	// change, backfill and version commit together, so one that fails is
+	// rolled back whole and its ADD COLUMN runs again cleanly on retry
+	migrationSQL: func(stmts string) string {
+		return strings.ReplaceAll(stmts, "ADD COLUMN IF NOT EXISTS", "ADD COLUMN")
+	},
+}
+
+// sqliteConnector opens connections that store every time in UTC. SQLite
+// keeps times as text, which only compares correctly in one zone.
+type sqliteConnector struct {
+	dsn string
+}
+
+func (c sqliteConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	full, ok := conn.(sqliteConn)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("sqlite driver connection lacks context support")
+	}
+	return utcConn{full}, nil
+}
+
+func (sqliteConnector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// sqliteConn is the driver connection's method set, kept whole so the
+// wrapper doesn't hide any of it from database/sql.
+type sqliteConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+type utcConn struct {
+	sqliteConn
+}
+
+// CheckNamedValue converts times to UTC, then leaves the value to the
+// default conversion.
+func (utcConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if t, ok := nv.Value.(time.Time); ok {
+		nv.Value = t.UTC()
+	}
+	return driver.ErrSkip
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// execer is a *sql.DB or a *sql.Tx, for writes that are sometimes part of a
+// larger transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// AddSuppression stores s and returns its ID.
+func (r *sqlRepo) AddSuppression(ctx context.Context, s model.Suppression) (string, error) {
+	return addSuppression(ctx, r.db, s)
+}
+
+func addSuppression(ctx context.Context, db execer, s model.Suppression) (string, error) {
+	if s.ABN == "" && s.NamePattern == "" {
+		return "", fmt.Errorf("a suppression needs an ABN or a name pattern")
+	}
+	if s.ID == "" {
+		b := make([]byte, 6)
+		_, _ = rand.Read(b)
+		s.ID = hex.EncodeToString(b)
+	}
+	if s.CreatedAt.IsZero() {
+		s.CreatedAt = time.Now()
+	}
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO suppressions (id, abn, name_pattern, source, reason, author, created_at, expires_at, batch_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, nullString(s.ABN), nullString(s.NamePattern), nullString(s.Source), s.Reason, s.Author, s.CreatedAt, nullTime(s.ExpiresAt), nullString(s.BatchID))
+	return s.ID, err
+}
+
+// RemoveSuppression deletes a suppression, reporting whether it existed.
+func (r *sqlRepo) RemoveSuppression(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM suppressions WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ListSuppressions returns the suppression list, oldest first, leaving out
+// expired entries unless includeExpired.
+func (r *sqlRepo) ListSuppressions(ctx context.Context, includeExpired bool) (model.Suppressions, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, coalesce(abn, ''), coalesce(name_pattern, ''), coalesce(source, ''), coalesce(reason, ''), coalesce(author, ''), created_at, expires_at, coalesce(batch_id, '')
+		FROM suppressions ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var list model.Suppressions
+	for rows.Next() {
+		var s model.Suppression
+		var created, expires sql.NullTime
+		if err := rows.Scan(&s.ID, &s.ABN, &s.NamePattern, &s.Source, &s.Reason, &s.Author, &created, &expires, &s.BatchID); err != nil {
+			return nil, err
+		}
+		s.CreatedAt = created.Time
+		s.ExpiresAt = expires.Time
+		if includeExpired || s.Active(now) {
+			list = append(list, s)
+		}
+	}
+	return list, rows.Err()
+}
+
+// suppressedSQL returns a condition matching leads the list suppresses. A
+// suppression scoped to one source only hides leads no other source lists.
+func suppressedSQL(list model.Suppressions, now time.Time) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	for _, s := range list {
+		if !s.Active(now) {
+			continue
+		}
+		var match []string
+		if s.ABN != "" {
+			match = append(match, "leads.abn = ?")
+			args = append(args, s.ABN)
+		}
+		if s.NamePattern != "" {
+			match = append(match, `lower(leads.name) LIKE ? ESCAPE '\'`)
+			args = append(args, globToLike(s.NamePattern))
+		}
+		cond := "(" + strings.Join(match, " OR ") + ")"
+		if s.Source != "" {
+			cond += " AND NOT EXISTS (SELECT 1 FROM lead_sources ls WHERE ls.abn = leads.abn AND ls.source <> ?)"
+			args = append(args, s.Source)
+		}
+		conds = append(conds, "("+cond+")")
+	}
+	if len(conds) == 0 {
+		return "FALSE", nil
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", args
+}
+
+// globToLike turns a suppression name pattern into a lowercase LIKE pattern.
+func globToLike(pattern string) string {
+	return strings.ReplaceAll(escapeLike(strings.ToLower(strings.TrimSpace(pattern))), "*", "%")
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// TagLeads tags the leads q selects with each of tags, normalised. Tags a lead
+// already has are left as they were. It returns how many tags were added.
+func (r *sqlRepo) TagLeads(ctx context.Context, q LeadQuery, tags []string, author string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	where, args := q.where(now)
+	abns, err := queryStrings(ctx, tx, fmt.Sprintf("SELECT leads.abn FROM %s WHERE %s", leadsFrom, where), args...)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, tag := range tags {
+		if tag = model.NormalizeTag(tag); tag == "" {
+			continue
+		}
+		for _, abn := range abns {
+			result, err := tx.ExecContext(ctx, `
+				INSERT INTO lead_tags (abn, tag, author, tagged_at) VALUES (?, ?, ?, ?)
+				ON CONFLICT (abn, tag) DO NOTHING`,
+				abn, tag, nullString(author), now)
+			if err != nil {
+				return 0, err
+			}
+			n, err := result.RowsAffected()
+			if err != nil {
+				return 0, err
+			}
+			added += int(n)
+		}
+	}
+	return added, tx.Commit()
+}
+
+// UntagLeads removes each of tags from the leads q selects. It returns how
+// many tags were removed.
+func (r *sqlRepo) UntagLeads(ctx context.Context, q LeadQuery, tags []string) (int, error) {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = model.NormalizeTag(tag); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) == 0 {
+		return 0, nil
+	}
+	in, tagArgs := inList(normalized)
+	where, args := q.where(time.Now())
+	result, err := r.db.ExecContext(ctx, fmt.Sprintf(`
+		DELETE FROM lead_tags WHERE tag IN %s
+		AND abn IN (SELECT leads.abn FROM %s WHERE %s)`, in, leadsFrom, where),
+		append(tagArgs, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// ListTags returns the tags in use, alphabetically, with how many leads carry
+// each.
+func (r *sqlRepo) ListTags(ctx context.Context) ([]model.TagCount, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT tag, count(*) FROM lead_tags GROUP BY tag ORDER BY tag")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []model.TagCount
+	for rows.Next() {
+		var t model.TagCount
+		if err := rows.Scan(&t.Tag, &t.Leads); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// GetLeadTags returns a lead's tags, alphabetically.
+func (r *sqlRepo) GetLeadTags(ctx context.Context, abn string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT tag FROM lead_tags WHERE abn = ? ORDER BY tag", abn)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tags []string
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// AddToList adds the leads q selects now to the named list, creating it if
+// need be. The list doesn't follow q afterwards. It returns how many leads
+// were added; ones already on the list don't count.
+func (r *sqlRepo) AddToList(ctx context.Context, name string, q LeadQuery, author string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("a list needs a name")
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, "INSERT INTO lead_lists (name, author, created_at) VALUES (?, ?, ?) ON CONFLICT (name) DO NOTHING",
+		name, nullString(author), now); err != nil {
+		return 0, err
+	}
+	where, args := q.where(now)
+	abns, err := queryStrings(ctx, tx, fmt.Sprintf("SELECT leads.abn FROM %s WHERE %s", leadsFrom, where), args...)
+	if err != nil {
+		return 0, err
+	}
+	added := 0
+	for _, abn := range abns {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO lead_list_members (list, abn, added_at) VALUES (?, ?, ?)
+			ON CONFLICT (list, abn) DO NOTHING`,
+			name, abn, now)
+		if err != nil {
+			return 0, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		added += int(n)
+	}
+	return added, tx.Commit()
+}
+
+// RemoveFromList takes the leads q selects off the named list. It returns
+// how many were removed.
+func (r *sqlRepo) RemoveFromList(ctx context.Context, name string, q LeadQuery) (int, error) {
+	where, args := q.where(time.Now())
+	result, err := r.db.ExecContext(ctx, fmt.Sprintf(`
+		DELETE FROM lead_list_members WHERE list = ?
+		AND abn IN (SELECT leads.abn FROM %s WHERE %s)`, leadsFrom, where),
+		append([]interface{}{strings.TrimSpace(name)}, args...)...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// DeleteList deletes the named list, leaving its leads be. It reports whether
+// the list existed.
+func (r *sqlRepo) DeleteList(ctx context.Context, name string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	name = strings.TrimSpace(name)
+	if _, err := tx.ExecContext(ctx, "DELETE FROM lead_list_members WHERE list = ?", name); err != nil {
+		return false, err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM lead_lists WHERE name = ?", name)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// GetList returns the named list, or nil if there's no such list.
+func (r *sqlRepo) GetList(ctx context.Context, name string) (*model.LeadList, error) {
+	lists, err := r.queryLists(ctx, "WHERE l.name = ?", strings.TrimSpace(name))
+	if err != nil || len(lists) == 0 {
+		return nil, err
+	}
+	return &lists[0], nil
+}
+
+// ListLists returns every list, oldest first, with its size.
+func (r *sqlRepo) ListLists(ctx context.Context) ([]model.LeadList, error) {
+	return r.queryLists(ctx, "")
+}
+
+func (r *sqlRepo) queryLists(ctx context.Context, where string, args ...interface{}) ([]model.LeadList, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT l.name, coalesce(l.author, ''), l.created_at,
+			(SELECT count(*) FROM lead_list_members m WHERE m.list = l.name)
+		FROM lead_lists l `+where+`
+		ORDER BY l.created_at, l.name`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var lists []model.LeadList
+	for rows.Next() {
+		var l model.LeadList
+		var created sql.NullTime
+		if err := rows.Scan(&l.Name, &l.Author, &created, &l.Leads); err != nil {
+			return nil, err
+		}
+		l.CreatedAt = created.Time
+		lists = append(lists, l)
+	}
+	return lists, rows.Err()
+}
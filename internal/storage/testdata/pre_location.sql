@@ -0,0 +1,30 @@
+-- A database as the first release left it: leads with postcodes, but no
+-- location columns and no schema_migrations.
+CREATE TABLE leads (
+	abn TEXT PRIMARY KEY,
+	name TEXT,
+	category TEXT,
+	sources TEXT,
+	entity_type TEXT,
+	entity_status TEXT,
+	state TEXT,
+	postcode TEXT,
+	registration_date TIMESTAMP,
+	age_years INTEGER,
+	gst_registered BOOLEAN,
+	gst_effective_from TIMESTAMP,
+	is_current_entity BOOLEAN,
+	acn TEXT,
+	main_trading_name TEXT,
+	phone TEXT,
+	email TEXT,
+	business_url TEXT,
+	found_at_url TEXT,
+	updated_at TIMESTAMP
+);
+
+INSERT INTO leads (abn, name, sources, entity_type, state, postcode) VALUES
+	('11000000001', 'Acme Engineering Pty Ltd', 'AMTIL', 'Australian Private Company', 'VIC', '3175'),
+	('22000000002', 'Bolt Holdings Ltd', 'SEMMA', 'Australian Public Company', 'VIC', '3000'),
+	('33000000003', 'Coil Services Pty Ltd', 'SEMMA', 'Australian Private Company', 'NSW', '2000'),
+	('44000000004', 'Dyno Fabrication Pty Ltd', 'AMTIL', 'Australian Private Company', 'NSW', '2999');
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shanehull/sourcerer/internal/model"
+)
+
+// changeTypeColumn leads a delta export, saying why each lead is in it.
+var changeTypeColumn = ExportColumn{Name: "change_type", Kind: KindText}
+
+// GetExportWatermark returns the named watermark, or nil if nothing has been
+// exported under the name yet.
+func (r *sqlRepo) GetExportWatermark(ctx context.Context, name string) (*model.ExportWatermark, error) {
+	var w model.ExportWatermark
+	var exportedAt sql.NullTime
+	var runID sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+		SELECT name, exported_at, run_id,
+			(SELECT count(*) FROM export_watermark_leads WHERE watermark = export_watermarks.name AND in_export)
+		FROM export_watermarks WHERE name = ?`, name).Scan(&w.Name, &exportedAt, &runID, &w.Leads)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	w.ExportedAt = exportedAt.Time
+	w.RunID = runID.String
+	return &w, nil
+}
+
+// ExportDelta is ExportLeads for the leads that changed against the named
+// watermark: those q selects that were never handed off, were dropped and
+// are back, or had a tracked field change since; and those handed off that
+// are no longer selected, whether q has stopped matching them or they've
+// been suppressed or deleted. A change_type column leads the given columns;
+// a deleted lead comes last, with only its ABN. The rows record what was
+// exported, for AdvanceExportWatermark.
+func (r *sqlRepo) ExportDelta(ctx context.Context, q LeadQuery, columns []ExportColumn, watermark string) (*ExportRows, error) {
+	if len(columns) == 0 {
+		columns = ExportColumns
+	}
+	since := time.Time{}
+	w, err := r.GetExportWatermark(ctx, watermark)
+	if err != nil {
+		return nil, err
+	}
+	if w != nil {
+		since = w.ExportedAt
+	}
+
+	// Handed-off leads that have since been deleted can't be selected, so
+	// they're read first and follow the rows
+	gone, err := queryStrings(ctx, r.db, `
+		SELECT abn FROM export_watermark_leads
+		WHERE watermark = ? AND in_export AND abn NOT IN (SELECT abn FROM leads)
+		ORDER BY abn`, watermark)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	where, args := q.where(now)
+	selected := "coalesce((" + where + "), FALSE)"
+	suppressed, suppressedArgs, err := r.suppressionFilter(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	if suppressed != "" {
+		selected += " AND NOT coalesce(" + suppressed + ", FALSE)"
+		args = append(args, suppressedArgs...)
+	}
+	exprs, selectArgs := exportExprs(q, columns)
+	args = append(args, selectArgs...)
+	args = append(args, watermark, since)
+
+	// Whether each lead is selected is needed for the filter and the change
+	// type both, so it's worked out once
+	query := fmt.Sprintf(`
+		WITH delta AS (
+			SELECT leads.abn, %s AS selected FROM %s
+		)
+		SELECT CASE
+				WHEN NOT delta.selected THEN 'dropped'
+				WHEN wl.abn IS NULL THEN 'new'
+				WHEN NOT wl.in_export THEN 'reactivated'
+				ELSE 'updated'
+			END AS change_type, %s, leads.abn
+		FROM %s
+		JOIN delta ON delta.abn = leads.abn
+		LEFT JOIN export_watermark_leads wl ON wl.abn = leads.abn AND wl.watermark = ?
+		WHERE (delta.selected AND (wl.abn IS NULL OR NOT wl.in_export
+				OR EXISTS (SELECT 1 FROM lead_history h WHERE h.abn = leads.abn AND h.changed_at > ?)))
+			OR (NOT delta.selected AND wl.in_export)
+		%s`, selected, leadsFrom, strings.Join(exprs, ", "), leadsFrom, q.orderBy())
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	exported := newExportRows(rows, append([]ExportColumn{changeTypeColumn}, columns...), 1)
+	exported.changes = make(map[string]model.ExportChange)
+	exported.gone = gone
+	return exported, nil
+}
+
+// AdvanceExportWatermark moves the named watermark to at, recording the
+// changes a delta export handed off so the next one starts from there.
+func (r *sqlRepo) AdvanceExportWatermark(ctx context.Context, name string, at time.Time, changes map[string]model.ExportChange) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for abn, change := range changes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO export_watermark_leads (watermark, abn, in_export, change_type, exported_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (watermark, abn) DO UPDATE SET
+				in_export = EXCLUDED.in_export,
+				change_type = EXCLUDED.change_type,
+				exported_at = EXCLUDED.exported_at`,
+			name, abn, change.InExport(), string(change), at); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO export_watermarks (name, exported_at, run_id) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET exported_at = EXCLUDED.exported_at, run_id = EXCLUDED.run_id`,
+		name, at, nullString(r.runID)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}